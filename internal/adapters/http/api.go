@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"strings"
 	"wemaps/internal/adapters/http/dto"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+	"wemaps/internal/services"
 )
 
 func (s *Server) getSingleAddressCoordsHandler(w http.ResponseWriter, r *http.Request) {
@@ -28,6 +31,17 @@ func (s *Server) getSingleAddressCoordsHandler(w http.ResponseWriter, r *http.Re
 	// Sanitize address
 	address = sanitizeString(address)
 
+	// Con min_precision se aceptan resultados menos exactos y se informan las alternativas
+	if value := r.URL.Query().Get("min_precision"); value != "" {
+		minPrecision, err := services.ParsePrecision(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.candidatesResponse(w, r, address, minPrecision)
+		return
+	}
+
 	// Check local database for similar address
 	var geo dto.WeMapsAddress
 	geo, err := s.portalService.FindAddreessWemaps(address)
@@ -58,7 +72,8 @@ func (s *Server) getSingleAddressCoordsHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	// Fallback to external geocoder
-	geoFromCoords, err := s.coordService.GetCoordsFromAddress(address)
+	// Si el cliente se desconecta se abandona la consulta a los proveedores
+	geoFromCoords, err := s.coordService.GetCoordsFromAddress(r.Context(), address)
 	if err != nil {
 		// Handle external geocoder error
 		response := dto.WeMapsAddress{
@@ -93,6 +108,51 @@ func (s *Server) getSingleAddressCoordsHandler(w http.ResponseWriter, r *http.Re
 	}
 }
 
+// candidateResponse es un candidato de /api/coordinates con min_precision
+type candidateResponse struct {
+	FormattedAddress string              `json:"formatted_address"`
+	Latitude         float64             `json:"latitude"`
+	Longitude        float64             `json:"longitude"`
+	Precision        domain.Precision    `json:"precision"`
+	Confidence       float64             `json:"confidence"`
+	Geocoder         string              `json:"geocoder"`
+	MatchScore       float64             `json:"match_score,omitempty"`
+	Alternatives     []candidateResponse `json:"alternatives,omitempty"`
+}
+
+func newCandidateResponse(geo domain.Geolocation) candidateResponse {
+	response := candidateResponse{
+		FormattedAddress: geo.FormattedAddress,
+		Latitude:         geo.Latitude,
+		Longitude:        geo.Longitude,
+		Precision:        geo.Precision,
+		Confidence:       geo.Confidence,
+		Geocoder:         geo.Geocoder,
+		MatchScore:       geo.MatchScore,
+	}
+	for _, alternative := range geo.Alternatives {
+		response.Alternatives = append(response.Alternatives, newCandidateResponse(alternative))
+	}
+	return response
+}
+
+// candidatesResponse responde el mejor candidato con precisión minPrecision o mejor y sus alternativas
+func (s *Server) candidatesResponse(w http.ResponseWriter, r *http.Request, address string, minPrecision domain.Precision) {
+	geo, err := s.coordService.GetCandidates(r.Context(), address, minPrecision)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":      err.Error(),
+			"error_kind": string(geocoders.Classify(err)),
+		})
+		return
+	}
+	if err := json.NewEncoder(w).Encode(newCandidateResponse(geo)); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
 func (s *Server) getTokenHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
@@ -1,40 +1,67 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
 	"wemaps/internal/services"
 
 	"github.com/google/uuid"
 )
 
+// GeoReport es el evento SSE de una fila. La sesión lo conserva para reenviarlo hasta sessionRetention,
+// por eso guarda solo los campos que también se reconstruyen al retomar una tarea y no la respuesta del proveedor.
 type GeoReport struct {
-	Geo      domain.Geolocation `json:"geo"`
-	Index    int                `json:"index"`
-	IdReport int                `json:"-"` //nolint
-	IdUser   int                `json:"-"` //nolint
+	Geo       RowGeo `json:"geo"`
+	Index     int    `json:"index"`
+	Duplicate bool   `json:"duplicate,omitempty"`
 }
 
-const (
-	LOAD_INIT     = 1
-	LOAD_FINISH   = 3
-	LOAD_ERROR    = 4
-	STILL_WORKING = 2
-)
+// RowGeo son los campos de domain.Geolocation que recibe el cliente por cada fila, con los mismos nombres JSON
+type RowGeo struct {
+	OriginAddress    string                 `json:"origin_address"`
+	FormattedAddress string                 `json:"formatted_address"`
+	Latitude         float64                `json:"latitude"`
+	Longitude        float64                `json:"longitude"`
+	Geocoder         string                 `json:"geocoder"`
+	Status           domain.StatusGeoResult `json:"status"`
+	Precision        domain.Precision       `json:"precision,omitempty"`
+	MatchScore       float64                `json:"match_score,omitempty"`
+	Consensus        *domain.Consensus      `json:"consensus,omitempty"`
+}
 
-// ReportSession almacena el reporte y su canal de resultados por sesión
-type ReportSession struct {
-	Report    services.CoordsReportRequest
-	ResultCh  chan GeoReport
-	DoneCh    chan struct{}
-	CreatedAt time.Time
+func newRowGeo(geo domain.Geolocation) RowGeo {
+	return RowGeo{
+		OriginAddress:    geo.OriginAddress,
+		FormattedAddress: geo.FormattedAddress,
+		Latitude:         geo.Latitude,
+		Longitude:        geo.Longitude,
+		Geocoder:         geo.Geocoder,
+		Status:           geo.Status,
+		Precision:        geo.Precision,
+		MatchScore:       geo.MatchScore,
+		Consensus:        geo.Consensus,
+	}
 }
 
+const (
+	LOAD_INIT      = 1
+	LOAD_FINISH    = 3
+	LOAD_ERROR     = 4
+	STILL_WORKING  = 2
+	LOAD_PAUSED    = 5
+	LOAD_CANCELLED = 6
+)
+
 func (s *Server) submitCoordsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
@@ -67,6 +94,26 @@ func (s *Server) submitCoordsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(report.Columns) == 0 {
+		http.Error(w, "No columns in report", http.StatusBadRequest)
+		return
+	}
+
+	if err := report.RowMapping().Validate(report.Columns); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid address mapping: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := report.ValidateOptions(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.startReport(w, user.ID, report)
+}
+
+// startReport gatilla la tarea del reporte y comienza a procesarlo en segundo plano.
+// Retorna true si se creó una tarea nueva, que desde entonces es dueña del archivo subido del reporte.
+func (s *Server) startReport(w http.ResponseWriter, userID int, report services.CoordsReportRequest) bool {
 	if report.ReportName == "" {
 		now := time.Now()
 		formattedTime := now.Format("2006-01-02_150405")
@@ -76,23 +123,26 @@ func (s *Server) submitCoordsHandler(w http.ResponseWriter, r *http.Request) {
 	// Generar un ID único para la sesión
 	sessionID := uuid.New().String()
 
-	// Crear canales para la sesión
-	resultCh := make(chan GeoReport, 1)
-	doneCh := make(chan struct{})
-
-	// Guardar la sesión en el mapa
-	s.sessionsMutex.Lock()
-	s.sessions[sessionID] = &ReportSession{
-		Report:    report,
-		ResultCh:  resultCh,
-		DoneCh:    doneCh,
-		CreatedAt: time.Now(),
+	// La tarea es unica por definicion, si ya fue gatillada se notifica su estado
+	job, created, err := s.jobService.Trigger(userID, sessionID, report)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error creating job: %v", err), http.StatusInternalServerError)
+		return false
 	}
-	s.sessionsMutex.Unlock()
+	if !created {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(services.StatusPayload(job))
+		return false
+	}
+
+	// El procesamiento no depende de la conexión del cliente
+	session := s.addReportSession(sessionID, job.IdTask, userID, report)
+	go s.processReport(sessionID, session, 0, -1)
 
 	// Devolver el sessionID al cliente
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"sessionID": sessionID})
+	json.NewEncoder(w).Encode(map[string]string{"sessionID": sessionID, "idtask": job.IdTask})
+	return true
 }
 
 func (s *Server) getCoordsHandler(w http.ResponseWriter, r *http.Request) {
@@ -137,6 +187,10 @@ func (s *Server) getCoordsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
 		return
 	}
+	if user.ID != session.UserID {
+		http.Error(w, "Invalid sessionID", http.StatusBadRequest)
+		return
+	}
 
 	fmt.Println("Usuario autenticado:", user.Alias)
 
@@ -146,123 +200,50 @@ func (s *Server) getCoordsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	geolocationService := s.coordService
-	report := session.Report
+	// Las filas ya enviadas antes de una reconexión no se repiten
+	cursor := session.cursorAfter(lastEventID(r))
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	flusher.Flush()
 
-	if len(report.Columns) == 0 {
-		http.Error(w, "No columns in report", http.StatusBadRequest)
-		return
-	}
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
 
-	keyAddresToGeoCoding := report.Columns[0]
-	addressToGeoCoding := report.Values[keyAddresToGeoCoding]
-
-	// Goroutine para procesar direcciones
-	go func() {
-		defer func() {
-			close(session.DoneCh)
-			// Limpiar la sesión al terminar
-			s.sessionsMutex.Lock()
-			delete(s.sessions, sessionID)
-			s.sessionsMutex.Unlock()
-		}()
-
-		nok := 0
-		ok := 0
-		reportID := -1
-
-		for index, address := range addressToGeoCoding {
-			geo, err := geolocationService.GetCoordsFromAddress(address)
-
-			status := domain.StatusGeoResult{
-				Count:  index + 1,
-				Total:  len(addressToGeoCoding),
-				Ok:     ok,
-				Nok:    nok,
-				Result: err == nil,
-			}
-
-			infoReport := make(map[string]string)
-			for _, col := range report.Columns {
-				if col == keyAddresToGeoCoding {
-					infoReport[col] = address
-				} else {
-					infoReport[col] = report.Values[col][index]
-				}
+	// Bucle principal para enviar datos al cliente, primero las filas ya procesadas y luego el avance
+	for {
+		events, updated, done := session.next(cursor)
+		for _, geo := range events {
+			if err := writeEvent(w, sseEventRow, strconv.Itoa(geo.Index), geo); err != nil {
+				// Cliente desconectado, el procesamiento continúa
+				return
 			}
-
-			if err != nil {
-				nok++
-				geo = domain.Geolocation{
-					Status:           status,
-					OriginAddress:    address,
-					FormattedAddress: address,
-					Latitude:         0,
-					Longitude:        0,
-					Geocoder:         "Sin Información: " + err.Error(),
-				}
-				infoReport["Dirección Normalizada"] = "-"
-				infoReport["Latitud"] = fmt.Sprintf("%f", geo.Latitude)
-				infoReport["Longitud"] = fmt.Sprintf("%f", geo.Longitude)
-			} else {
-				ok++
-				geo.Status = status
-				geo.OriginAddress = address
-				infoReport["Dirección Normalizada"] = geo.FormattedAddress
-				infoReport["Latitud"] = fmt.Sprintf("%f", geo.Latitude)
-				infoReport["Longitud"] = fmt.Sprintf("%f", geo.Longitude)
+			cursor++
+		}
+		if len(events) > 0 || done {
+			if err := writeEvent(w, sseEventProgress, "", session.progress()); err != nil {
+				return
 			}
+			flusher.Flush()
+		}
 
-			// Guardar en el portal
-
-			reportID, _ = s.saveToPortal(user.ID, reportID, geo, report.ReportName, infoReport, token, index)
-
-			fmt.Println("Reporte:", report.ReportName, " Origen : ["+geo.Geocoder+"] Dirección:", geo.FormattedAddress)
-			// Enviar resultado al canal
-			gr := GeoReport{
-				Geo:      geo,
-				Index:    index,
-				IdReport: reportID,
-				IdUser:   user.ID,
+		if done {
+			// Procesamiento completado, un error de la tarea se informa antes del cierre
+			progress := session.progress()
+			if progress.Status == domain.JobStatusError {
+				writeEvent(w, sseEventError, "", map[string]string{"message": progress.Message})
 			}
-
-			//s.portalService.SetStatusReport(gr.IdUser, gr.IdReport, STILL_WORKING)
-
-			select {
-			case session.ResultCh <- gr:
-			case <-r.Context().Done():
-				// Cliente desconectado, continuar procesando
-				//s.portalService.SetStatusReport(gr.IdUser, gr.IdReport, LOAD_ERROR)
+			if err := writeEvent(w, sseEventDone, "", map[string]string{"status": progress.Status}); err == nil {
+				flusher.Flush()
 			}
+			return
 		}
-		s.addressUnique = []string{}
-		s.portalService.SetStatusReport(user.ID, reportID, LOAD_FINISH)
-	}()
 
-	// Bucle principal para enviar datos al cliente
-	for {
 		select {
-		case geo, ok := <-session.ResultCh:
-			if !ok {
-				// Procesamiento completado
-				_, err := fmt.Fprintf(w, "data: {\"status\": \"done\"}\n\n")
-				if err == nil {
-					go s.portalService.SetStatusReport(geo.IdUser, geo.IdReport, LOAD_FINISH)
-					time.Sleep(2 * time.Second)
-					flusher.Flush()
-				}
-				return
-			}
-			// Enviar datos al cliente
-			data, _ := json.Marshal(geo)
-			_, err := fmt.Fprintf(w, "data: %s\n\n", data)
-			time.Sleep(3 * time.Second)
-			if err != nil {
-				s.portalService.SetStatusReport(geo.IdUser, geo.IdReport, LOAD_ERROR)
+		case <-updated:
+		case <-heartbeat.C:
+			if err := writeHeartbeat(w); err != nil {
 				return
 			}
 			flusher.Flush()
-
 		case <-r.Context().Done():
 			// Cliente canceló el request, pero la goroutine sigue procesando
 			return
@@ -270,20 +251,156 @@ func (s *Server) getCoordsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *Server) saveToPortal(userID, reportID int, geo domain.Geolocation, reportName string, infoReport map[string]string, token string, index int) (int, error) {
+// processReport geocodifica las filas del reporte desde start y publica cada resultado en la sesión.
+// Cuando reportID es -1 el reporte se crea con la tarea como hash de instancia, igual al reanudarlo.
+func (s *Server) processReport(sessionID string, session *ReportSession, start int, reportID int) {
+	defer s.closeReportSession(sessionID, session)
+	// Terminada, cancelada o con error la tarea ya no necesita el archivo subido
+	if source := session.Report.Source; source != nil {
+		defer services.ReleaseUpload(source.Path)
+	}
+
+	report := session.Report
+	total := report.TotalRows()
+
+	ok, nok := session.counts()
+	processed := start
+	session.setCheckpoint(processed)
+	s.jobService.Progress(session.JobID, processed)
+
+	rows, err := report.OpenRows()
+	if err != nil {
+		session.finish(domain.JobStatusError, err.Error())
+		s.jobService.Fail(session.JobID, processed, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
 
-	found := false
-	for _, addr := range s.addressUnique {
-		if addr == geo.FormattedAddress {
-			found = true
+	// Saltar las filas ya procesadas antes del checkpoint
+	for i := 0; i < start; i++ {
+		if _, err := rows.Next(); err != nil {
 			break
 		}
 	}
-	if !found {
-		s.addressUnique = append(s.addressUnique, geo.FormattedAddress)
+	columns := rows.Columns()
+	// Las columnas de geocodificación se guardan después de las originales
+	columnOrder := slices.Concat(columns, services.GeocodingColumns)
+
+	// Un reporte inverso obtiene la dirección desde las columnas de coordenadas
+	geocodeBatch := s.coordService.GeocodeBatch
+	if report.Reverse() {
+		geocodeBatch = s.coordService.ReverseGeocodeBatch
+	} else if minPrecision, _ := services.ParsePrecision(report.MinPrecision); minPrecision != "" {
+		// Con precisión mínima se aceptan candidatos menos exactos y se guardan sus alternativas
+		geocodeBatch = func(ctx context.Context, rows services.ReportRows, address func(row []string) string, onResult func(services.BatchResult) error) error {
+			return s.coordService.GeocodeBatchCandidates(ctx, rows, address, minPrecision, onResult)
+		}
+	} else if report.Consensus != nil {
+		// En modo consenso cada dirección se compara entre varios proveedores
+		geocodeBatch = func(ctx context.Context, rows services.ReportRows, address func(row []string) string, onResult func(services.BatchResult) error) error {
+			return s.coordService.GeocodeBatchConsensus(ctx, rows, address, *report.Consensus, onResult)
+		}
 	}
 
-	return s.portalService.SaveReportInfo(userID, reportID, reportName, infoReport, geo, token, index)
+	// Una carga pausada deja de leer filas, así no se consulta a los proveedores, y retiene las que ya estaban
+	// en consulta hasta que se reanude o se cancele
+	waitIfPaused := func() error {
+		if session.isPaused() {
+			s.jobService.Pause(session.JobID, session.getCheckpoint())
+		}
+		return session.waitWhilePaused()
+	}
+	ctx := services.WithRowGate(session.ctx, waitIfPaused)
+
+	err = geocodeBatch(ctx, rows, report.RowMapping().RowComposer(columns), func(result services.BatchResult) error {
+		if err := waitIfPaused(); err != nil {
+			return err
+		}
+		index, address, geo, err := result.Index+start, result.Address, result.Geo, result.Err
+
+		status := domain.StatusGeoResult{
+			Count:  index + 1,
+			Total:  total,
+			Ok:     ok,
+			Nok:    nok,
+			Result: err == nil,
+		}
+
+		infoReport := make(map[string]string)
+		for i, col := range columns {
+			infoReport[col] = result.Row[i]
+		}
+
+		if err != nil {
+			nok++
+			status.ErrorKind = string(geocoders.Classify(err))
+			geo = domain.Geolocation{
+				Status:           status,
+				OriginAddress:    address,
+				FormattedAddress: address,
+				Latitude:         0,
+				Longitude:        0,
+				Geocoder:         "Sin Información: " + err.Error(),
+			}
+			infoReport["Dirección Normalizada"] = "-"
+			infoReport["Latitud"] = fmt.Sprintf("%f", geo.Latitude)
+			infoReport["Longitud"] = fmt.Sprintf("%f", geo.Longitude)
+			infoReport[services.ColumnErrorKind] = status.ErrorKind
+			infoReport[services.ColumnPrecision] = ""
+			infoReport[services.ColumnAlternatives] = ""
+			infoReport[services.ColumnMatchScore] = ""
+			maps.Copy(infoReport, services.ConsensusColumns(nil))
+		} else {
+			ok++
+			geo.Status = status
+			geo.OriginAddress = address
+			infoReport["Dirección Normalizada"] = geo.FormattedAddress
+			infoReport["Latitud"] = fmt.Sprintf("%f", geo.Latitude)
+			infoReport["Longitud"] = fmt.Sprintf("%f", geo.Longitude)
+			infoReport[services.ColumnErrorKind] = ""
+			infoReport[services.ColumnPrecision] = string(geo.Precision)
+			infoReport[services.ColumnAlternatives] = services.FormatAlternatives(geo.Alternatives)
+			infoReport[services.ColumnMatchScore] = services.FormatMatchScore(geo.MatchScore)
+			maps.Copy(infoReport, services.ConsensusColumns(geo.Consensus))
+		}
+
+		// Guardar en el portal
+		previousReportID := reportID
+		reportID, err = s.portalService.SaveReportInfo(session.UserID, reportID, report.ReportName, infoReport, columnOrder, geo, session.JobID, index)
+		if reportID > 0 && previousReportID != reportID {
+			s.jobService.SetReport(session.JobID, reportID)
+		}
+		if err != nil {
+			// La fila no quedó guardada, el checkpoint no avanza y un reintento la vuelve a procesar
+			return err
+		}
+
+		// Checkpoint: las filas se entregan en orden, record_process es la siguiente fila a procesar
+		processed = index + 1
+		session.setCheckpoint(processed)
+		s.jobService.Progress(session.JobID, processed)
+
+		fmt.Println("Reporte:", report.ReportName, " Origen : ["+geo.Geocoder+"] Dirección:", geo.FormattedAddress)
+		session.publish(GeoReport{Geo: newRowGeo(geo), Index: index, Duplicate: result.Duplicate})
+		return nil
+	})
+	if session.isCancelled() {
+		// Las filas ya guardadas se mantienen en el reporte
+		session.finish(domain.JobStatusCancelled, "")
+		s.setReportStatus(session.UserID, reportID, LOAD_CANCELLED)
+		s.jobService.Cancel(session.JobID, processed)
+		return
+	}
+	if err != nil {
+		session.finish(domain.JobStatusError, err.Error())
+		s.setReportStatus(session.UserID, reportID, LOAD_ERROR)
+		s.jobService.Fail(session.JobID, processed, http.StatusInternalServerError, err.Error())
+		return
+	}
+	session.finish(domain.JobStatusFinish, "")
+	s.portalService.SetStatusReport(session.UserID, reportID, LOAD_FINISH)
+	progress := session.progress()
+	s.jobService.Finish(session.JobID, processed, progress.Duplicates, progress.Disputed)
 }
 
 // sanitizeString limpia una cadena para que sea válida en JSON
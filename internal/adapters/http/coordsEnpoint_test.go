@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+// Las filas del historial se envían con los mismos nombres y valores que la geolocalización completa
+func TestRowGeoJSON(t *testing.T) {
+	geo := domain.Geolocation{
+		OriginAddress:     "Av. Providencia 1234",
+		FormattedAddress:  "Avenida Providencia 1234, Providencia, Chile",
+		Latitude:          -33.4263,
+		Longitude:         -70.6109,
+		Geocoder:          "nominatim",
+		Status:            domain.StatusGeoResult{Count: 1, Ok: 1, Total: 10, Result: true},
+		ResponseCoordsApi: []interface{}{map[string]interface{}{"lat": "-33.4263"}},
+		Components:        map[string]string{"road": "Avenida Providencia"},
+		Precision:         domain.PrecisionRooftop,
+		Alternatives:      []domain.Geolocation{{FormattedAddress: "Providencia, Chile"}},
+		MatchScore:        0.95,
+		Consensus:         &domain.Consensus{Status: domain.ConsensusConfirmed, Agreeing: []string{"nominatim", "photon"}},
+	}
+
+	full := jsonFields(t, geo)
+	row := jsonFields(t, newRowGeo(geo))
+	for key, value := range row {
+		if !reflect.DeepEqual(full[key], value) {
+			t.Errorf("%s = %v, en la geolocalización es %v", key, value, full[key])
+		}
+	}
+	for _, key := range []string{"components", "alternatives", "confidence"} {
+		if _, ok := row[key]; ok {
+			t.Errorf("la fila del historial no debería guardar %s", key)
+		}
+	}
+}
+
+func jsonFields(t *testing.T, value any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
@@ -46,6 +46,15 @@ type ReportRow struct {
 	FilaTranspuesta map[string]string `json:"fila_transpuesta"`
 }
 
+// GeoReportRow es una fila del reporte con las coordenadas de su dirección.
+// Geocoder queda vacío cuando la fila no se pudo geocodificar.
+type GeoReportRow struct {
+	ReportRow
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Geocoder  string  `json:"geocoder"`
+}
+
 type CategoryCount struct {
 	Category string `json:"category"`
 	Total    int    `json:"total"`
@@ -0,0 +1,13 @@
+package dto
+
+type JobStatus struct {
+	Status string    `json:"Status"`
+	Detail JobDetail `json:"detail"`
+}
+
+type JobDetail struct {
+	IdTask        string `json:"idtask"`
+	RecordProcess string `json:"record_process,omitempty"`
+	IdError       string `json:"id_error,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
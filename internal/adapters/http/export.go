@@ -0,0 +1,125 @@
+package http
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"wemaps/internal/infrastructure/gis"
+	"wemaps/internal/infrastructure/spreadsheet"
+	"wemaps/internal/services"
+)
+
+// exportReportHandler descarga el reporte como GeoJSON, KML, GPX o Shapefile comprimido
+func (s *Server) exportReportHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := s.GetUserFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reportID, err := strconv.Atoi(r.URL.Query().Get("report_id"))
+	if err != nil {
+		http.Error(w, "Invalid report_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = gis.FormatGeoJSON
+	}
+	contentType, ext := gis.ContentType(format)
+	if ext == "" {
+		http.Error(w, fmt.Sprintf("Unsupported format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	// Solo el autor puede exportar el reporte
+	report, err := s.portalService.GetReportByReportUserID(user.ID, reportID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching report: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Name+ext))
+
+	// La respuesta ya comenzó, un error a mitad de la exportación solo se registra
+	if err := s.portalService.ExportReport(w, reportID, report.Name, format); err != nil {
+		log.Printf("Error exporting report %d: %v", reportID, err)
+	}
+}
+
+// downloadReportHandler descarga el reporte como CSV o XLSX con las columnas de geocodificación agregadas.
+// Para CSV se puede indicar delimiter y encoding; por defecto se usa UTF-8 con BOM para que Excel lo abra bien.
+func (s *Server) downloadReportHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := s.GetUserFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	reportID, err := strconv.Atoi(query.Get("report_id"))
+	if err != nil {
+		http.Error(w, "Invalid report_id parameter", http.StatusBadRequest)
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	csvFormat := spreadsheet.CSVFormat{Delimiter: ",", Encoding: spreadsheet.EncodingUTF8BOM}
+	var contentType string
+	switch format {
+	case "", services.SourceCSV:
+		format = services.SourceCSV
+		contentType = "text/csv"
+		if delimiter := query.Get("delimiter"); delimiter != "" {
+			if csvFormat.Delimiter, err = delimiterField(delimiter); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if encoding := query.Get("encoding"); encoding != "" {
+			csvFormat.Encoding = strings.ToLower(encoding)
+		}
+		switch csvFormat.Encoding {
+		case spreadsheet.EncodingUTF8, spreadsheet.EncodingUTF8BOM:
+			contentType += "; charset=utf-8"
+		case spreadsheet.EncodingWindows1252:
+			contentType += "; charset=windows-1252"
+		default:
+			http.Error(w, fmt.Sprintf("Unsupported encoding: %s", csvFormat.Encoding), http.StatusBadRequest)
+			return
+		}
+	case services.SourceXLSX:
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	// Solo el autor puede descargar el reporte
+	report, err := s.portalService.GetReportByReportUserID(user.ID, reportID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching report: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.Name+"."+format))
+
+	if err := s.portalService.ExportSpreadsheet(w, reportID, format, csvFormat); err != nil {
+		log.Printf("Error downloading report %d: %v", reportID, err)
+	}
+}
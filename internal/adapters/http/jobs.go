@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"wemaps/internal/domain"
+	"wemaps/internal/services"
+)
+
+func (s *Server) jobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := s.GetUserFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTask := r.PathValue("id")
+	if idTask == "" {
+		http.Error(w, "Missing job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.jobService.GetJob(user.ID, idTask)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(services.StatusPayload(job)); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// jobActionHandler cancela, pausa o reanuda la carga en curso de una tarea
+func (s *Server) jobActionHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := s.GetUserFromContext(r)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	idTask := r.PathValue("id")
+	job, err := s.jobService.GetJob(user.ID, idTask)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	session, exists := s.sessionByJob(idTask)
+	if !exists {
+		http.Error(w, "Job is not running", http.StatusConflict)
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "cancel":
+		if !session.stop() {
+			http.Error(w, "Job is not running", http.StatusConflict)
+			return
+		}
+		// El estado cancelado lo guarda processReport al terminar, con las filas que alcanzó a guardar
+		job.Status = domain.JobStatusCancelled
+	case "pause":
+		if !session.pause() {
+			http.Error(w, "Job is not running", http.StatusConflict)
+			return
+		}
+		job.Status = domain.JobStatusPaused
+		s.jobService.Pause(idTask, session.getCheckpoint())
+		s.setReportStatus(user.ID, job.ReportID, LOAD_PAUSED)
+	case "resume":
+		if !session.resume() {
+			http.Error(w, "Job is not paused", http.StatusConflict)
+			return
+		}
+		job.Status = domain.JobStatusInProcess
+		s.jobService.Resume(idTask, session.getCheckpoint())
+		s.setReportStatus(user.ID, job.ReportID, STILL_WORKING)
+	default:
+		http.Error(w, "Unknown action", http.StatusNotFound)
+		return
+	}
+	job.RecordProcess = session.getCheckpoint()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(services.StatusPayload(job)); err != nil {
+		log.Printf("Error encoding response: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// setReportStatus actualiza el estado del reporte si ya fue creado
+func (s *Server) setReportStatus(userID, reportID, status int) {
+	if reportID <= 0 {
+		return
+	}
+	if _, err := s.portalService.SetStatusReport(userID, reportID, status); err != nil {
+		log.Printf("Error setting report %d status to %d: %v", reportID, status, err)
+	}
+}
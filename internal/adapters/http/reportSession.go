@@ -0,0 +1,347 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"wemaps/internal/domain"
+	"wemaps/internal/services"
+)
+
+// sessionRetention es el tiempo que una sesión terminada sigue disponible para reconexiones
+const sessionRetention = 30 * time.Minute
+
+// ReportSession almacena el reporte y el historial de resultados por sesión
+type ReportSession struct {
+	JobID     string
+	UserID    int
+	Report    services.CoordsReportRequest
+	CreatedAt time.Time
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mu        sync.Mutex
+	history   []GeoReport
+	updated   chan struct{}
+	done      bool
+	paused    bool
+	resumed   chan struct{}
+	cancelled bool
+	// checkpoint es la siguiente fila a procesar
+	checkpoint int
+	ok, nok    int
+	duplicates int
+	// disputed y agreement resumen el modo consenso: filas en disputa y coincidencias de cada proveedor
+	disputed  int
+	agreement map[string]domain.ProviderAgreement
+	// outcome y message registran como terminó el procesamiento
+	outcome string
+	message string
+}
+
+func (s *Server) addReportSession(sessionID, jobID string, userID int, report services.CoordsReportRequest) *ReportSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &ReportSession{
+		JobID:     jobID,
+		UserID:    userID,
+		Report:    report,
+		CreatedAt: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+		updated:   make(chan struct{}),
+	}
+
+	s.sessionsMutex.Lock()
+	s.sessions[sessionID] = session
+	s.sessionsMutex.Unlock()
+	return session
+}
+
+// closeReportSession marca la sesión como terminada y la elimina pasado sessionRetention
+func (s *Server) closeReportSession(sessionID string, session *ReportSession) {
+	session.mu.Lock()
+	session.done = true
+	close(session.updated)
+	session.mu.Unlock()
+	session.cancel()
+
+	time.AfterFunc(sessionRetention, func() {
+		s.sessionsMutex.Lock()
+		delete(s.sessions, sessionID)
+		s.sessionsMutex.Unlock()
+	})
+}
+
+// publish agrega el resultado al historial y despierta a los clientes conectados
+func (rs *ReportSession) publish(gr GeoReport) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.history = append(rs.history, gr)
+	if gr.Geo.Status.Result {
+		rs.ok++
+	} else {
+		rs.nok++
+	}
+	if gr.Duplicate {
+		rs.duplicates++
+	}
+	rs.countAgreement(gr.Geo.Consensus)
+	close(rs.updated)
+	rs.updated = make(chan struct{})
+}
+
+// countAgreement suma a cada proveedor las filas en que coincidió o no con el resultado; sin otro proveedor
+// con qué comparar la fila no cuenta
+func (rs *ReportSession) countAgreement(consensus *domain.Consensus) {
+	if consensus == nil || consensus.Status == domain.ConsensusSingle {
+		return
+	}
+	if rs.agreement == nil {
+		rs.agreement = make(map[string]domain.ProviderAgreement)
+	}
+	if consensus.Status == domain.ConsensusDisputed {
+		rs.disputed++
+	}
+	for _, name := range consensus.Agreeing {
+		stats := rs.agreement[name]
+		stats.Agreed++
+		rs.agreement[name] = stats
+	}
+	for _, name := range consensus.Disagreeing {
+		stats := rs.agreement[name]
+		stats.Disagreed++
+		rs.agreement[name] = stats
+	}
+}
+
+// next retorna los resultados desde cursor, un canal que se cierra con el próximo resultado
+// y si el procesamiento ya terminó
+func (rs *ReportSession) next(cursor int) ([]GeoReport, <-chan struct{}, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	var events []GeoReport
+	if cursor < len(rs.history) {
+		events = rs.history[cursor:]
+	}
+	return events, rs.updated, rs.done
+}
+
+// cursorAfter retorna la posición en el historial de la primera fila posterior a index
+func (rs *ReportSession) cursorAfter(index int) int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return sort.Search(len(rs.history), func(i int) bool { return rs.history[i].Index > index })
+}
+
+// finish registra el estado final de la tarea antes de cerrar la sesión
+func (rs *ReportSession) finish(outcome, message string) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.outcome, rs.message = outcome, message
+}
+
+// progress resume el avance de la sesión para el stream de eventos
+func (rs *ReportSession) progress() sseProgress {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	status := domain.JobStatusInProcess
+	switch {
+	case rs.done && rs.outcome != "":
+		status = rs.outcome
+	case rs.cancelled:
+		status = domain.JobStatusCancelled
+	case rs.paused:
+		status = domain.JobStatusPaused
+	}
+	return sseProgress{
+		Status:     status,
+		Processed:  rs.checkpoint,
+		Total:      rs.Report.TotalRows(),
+		Ok:         rs.ok,
+		Nok:        rs.nok,
+		Duplicates: rs.duplicates,
+		Disputed:   rs.disputed,
+		Agreement:  maps.Clone(rs.agreement),
+		Message:    rs.message,
+	}
+}
+
+// pause detiene la entrega de filas; retorna false si la sesión no está corriendo
+func (rs *ReportSession) pause() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.done || rs.cancelled || rs.paused {
+		return false
+	}
+	rs.paused = true
+	rs.resumed = make(chan struct{})
+	return true
+}
+
+// resume reanuda una sesión pausada
+func (rs *ReportSession) resume() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.done || rs.cancelled || !rs.paused {
+		return false
+	}
+	rs.paused = false
+	close(rs.resumed)
+	return true
+}
+
+// stop cancela el procesamiento de la sesión
+func (rs *ReportSession) stop() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.done || rs.cancelled {
+		return false
+	}
+	rs.cancelled = true
+	rs.cancel()
+	return true
+}
+
+func (rs *ReportSession) isCancelled() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.cancelled
+}
+
+// waitWhilePaused bloquea mientras la sesión está pausada y retorna error si se cancela
+func (rs *ReportSession) waitWhilePaused() error {
+	rs.mu.Lock()
+	paused, resumed := rs.paused, rs.resumed
+	rs.mu.Unlock()
+	if paused {
+		select {
+		case <-resumed:
+		case <-rs.ctx.Done():
+		}
+	}
+	return rs.ctx.Err()
+}
+
+func (rs *ReportSession) isPaused() bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.paused
+}
+
+func (rs *ReportSession) setCheckpoint(row int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.checkpoint = row
+}
+
+func (rs *ReportSession) getCheckpoint() int {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.checkpoint
+}
+
+// sessionByJob busca la sesión en curso asociada a la tarea
+func (s *Server) sessionByJob(idTask string) (*ReportSession, bool) {
+	s.sessionsMutex.RLock()
+	defer s.sessionsMutex.RUnlock()
+	for _, session := range s.sessions {
+		if session.JobID == idTask {
+			return session, true
+		}
+	}
+	return nil, false
+}
+
+// counts retorna la cantidad de filas geocodificadas y fallidas del historial
+func (rs *ReportSession) counts() (int, int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.ok, rs.nok
+}
+
+// resumeReports retoma las tareas que quedaron sin terminar desde su último checkpoint
+func (s *Server) resumeReports() {
+	jobs, err := s.jobService.Unfinished()
+	if err != nil {
+		log.Printf("Error fetching unfinished jobs: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		var report services.CoordsReportRequest
+		if err := json.Unmarshal([]byte(job.Definition), &report); err != nil || len(report.Columns) == 0 {
+			s.jobService.Fail(job.IdTask, job.RecordProcess, http.StatusInternalServerError, fmt.Sprintf("invalid job definition: %v", err))
+			continue
+		}
+
+		if report.Source != nil {
+			services.AcquireUpload(report.Source.Path)
+		}
+		session := s.addReportSession(job.SessionID, job.IdTask, job.UserID, report)
+		if job.ReportID > 0 && job.RecordProcess > 0 {
+			s.restoreHistory(session, job)
+		}
+		if job.Status == domain.JobStatusPaused {
+			session.pause()
+		}
+
+		log.Printf("Resuming job %s for report %s from row %d", job.IdTask, job.ReportName, job.RecordProcess)
+		go s.processReport(job.SessionID, session, job.RecordProcess, job.ReportID)
+	}
+}
+
+// restoreHistory reconstruye las filas ya guardadas del reporte para reenviarlas a clientes que se reconectan
+func (s *Server) restoreHistory(session *ReportSession, job domain.Job) {
+	rows, _, err := s.portalService.GetReportRowsByReportID(job.ReportID, 0, job.RecordProcess)
+	if err != nil {
+		log.Printf("Error restoring history for job %s: %v", job.IdTask, err)
+		return
+	}
+
+	mapping := session.Report.RowMapping()
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		lat, _ := strconv.ParseFloat(row.FilaTranspuesta["Latitud"], 64)
+		lon, _ := strconv.ParseFloat(row.FilaTranspuesta["Longitud"], 64)
+		score, _ := strconv.ParseFloat(row.FilaTranspuesta[services.ColumnMatchScore], 64)
+		address := mapping.Compose(func(column string) string { return row.FilaTranspuesta[column] })
+		formatted := row.FilaTranspuesta["Dirección Normalizada"]
+		if formatted == "-" {
+			formatted = address
+		}
+
+		key := services.NormalizeAddress(address)
+		duplicate := seen[key]
+		seen[key] = true
+
+		ok, nok := session.counts()
+		session.publish(GeoReport{
+			Geo: RowGeo{
+				OriginAddress:    address,
+				FormattedAddress: formatted,
+				Latitude:         lat,
+				Longitude:        lon,
+				Status: domain.StatusGeoResult{
+					Count:     row.IndexColumn + 1,
+					Total:     session.Report.TotalRows(),
+					Ok:        ok,
+					Nok:       nok,
+					Result:    lat != 0 && lon != 0,
+					ErrorKind: row.FilaTranspuesta[services.ColumnErrorKind],
+				},
+				Precision:  domain.Precision(row.FilaTranspuesta[services.ColumnPrecision]),
+				MatchScore: score,
+				Consensus:  services.ParseConsensusColumns(row.FilaTranspuesta),
+			},
+			Index:     row.IndexColumn,
+			Duplicate: duplicate,
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"wemaps/internal/infrastructure/geocoders"
+	"wemaps/internal/services"
+)
+
+// reverseResponse es la dirección encontrada para un par de coordenadas
+type reverseResponse struct {
+	FormattedAddress string            `json:"formatted_address"`
+	Latitude         float64           `json:"latitude"`
+	Longitude        float64           `json:"longitude"`
+	Geocoder         string            `json:"geocoder"`
+	Components       map[string]string `json:"components,omitempty"`
+}
+
+// reverseGeocodeHandler retorna la dirección de /api/reverse?lat=..&lon=..
+func (s *Server) reverseGeocodeHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.GetUserFromContext(r); err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	lat, errLat := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	lon, errLon := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	if errLat != nil || errLon != nil {
+		http.Error(w, "Missing or invalid lat/lon query parameters", http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidateCoordinates(lat, lon); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Si el cliente se desconecta se abandona la consulta a los proveedores
+	geo, err := s.coordService.ReverseGeocode(r.Context(), lat, lon)
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusNotFound
+		if kind := geocoders.Classify(err); kind == geocoders.KindTransient || kind == geocoders.KindAuthQuota {
+			status = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":      err.Error(),
+			"error_kind": string(geocoders.Classify(err)),
+		})
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(reverseResponse{
+		FormattedAddress: geo.FormattedAddress,
+		Latitude:         geo.Latitude,
+		Longitude:        geo.Longitude,
+		Geocoder:         geo.Geocoder,
+		Components:       geo.Components,
+	}); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
@@ -13,18 +13,20 @@ type Server struct {
 	healthService *services.Health
 	coordService  *services.GeolocationService
 	portalService *services.PortalService
+	jobService    *services.JobService
 	reports       services.CoordsReportRequest
-	addressUnique []string
 	mu            sync.Mutex
 	sessions      map[string]*ReportSession //CEREBRO DE MULTISESION!!
 	sessionsMutex sync.RWMutex
 }
 
-func NewServer(repoAddress ports.GeolocationRepository, portalRepo ports.PortalRepository) *Server {
+func NewServer(repoAddress ports.GeolocationRepository, portalRepo ports.PortalRepository, jobRepo ports.JobRepository, geocodersConfig services.GeocodersConfig) *Server {
+	coordService := services.NewGeolocationService(repoAddress, portalRepo, geocodersConfig)
 	s := &Server{
-		healthService: services.NewHealthService(),
-		coordService:  services.NewGeolocationService(repoAddress, portalRepo),
+		healthService: services.NewHealthService(coordService),
+		coordService:  coordService,
 		portalService: services.NewPortalService(portalRepo),
+		jobService:    services.NewJobService(jobRepo),
 		reports:       services.CoordsReportRequest{},
 		sessions:      make(map[string]*ReportSession),
 	}
@@ -42,6 +44,9 @@ func fileServerWithHeaders(fs http.Handler) http.Handler {
 }
 
 func (s *Server) StartServer(port, certFile, keyFile string) error {
+	// Retomar las cargas que quedaron pendientes antes de aceptar conexiones
+	s.resumeReports()
+
 	mux := http.NewServeMux()
 
 	// Angular estático
@@ -51,9 +56,13 @@ func (s *Server) StartServer(port, certFile, keyFile string) error {
 	// Endpoints API
 	mux.HandleFunc("/api/health", s.AuthMiddleware(s.healthHandler))
 	mux.HandleFunc("/api/submitcoords", s.submitCoordsHandler)
+	mux.HandleFunc("/api/uploadcoords", s.uploadCoordsHandler)
 	mux.HandleFunc("/api/getcoords/", s.getCoordsHandler)
 	mux.HandleFunc("/api/coordinates", s.getSingleAddressCoordsHandler)
+	mux.HandleFunc("/api/reverse", s.AuthMiddleware(s.reverseGeocodeHandler))
 	mux.HandleFunc("/api/token", s.getTokenHandler)
+	mux.HandleFunc("/api/jobs/{id}", s.AuthMiddleware(s.jobStatusHandler))
+	mux.HandleFunc("/api/jobs/{id}/{action}", s.AuthMiddleware(s.jobActionHandler))
 
 	//login
 	mux.HandleFunc("/api/login", s.logInHandler)
@@ -64,6 +73,8 @@ func (s *Server) StartServer(port, certFile, keyFile string) error {
 	mux.HandleFunc("/portal/addressInfoPeerPage", s.AuthMiddleware(s.addressInfoHandlerPeerPage))
 	mux.HandleFunc("/portal/reports", s.AuthMiddleware(s.reportSummaryHandler))
 	mux.HandleFunc("/portal/report", s.AuthMiddleware(s.reportRowsHandler))
+	mux.HandleFunc("/portal/report/export", s.AuthMiddleware(s.exportReportHandler))
+	mux.HandleFunc("/portal/report/download", s.AuthMiddleware(s.downloadReportHandler))
 	mux.HandleFunc("/portal/countInfo", s.AuthMiddleware(s.countInfo))
 
 	addr := ":" + port
@@ -0,0 +1,76 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"wemaps/internal/domain"
+)
+
+// Tipos de evento del stream de avance de un reporte
+const (
+	sseEventRow      = "row"
+	sseEventProgress = "progress"
+	sseEventError    = "error"
+	sseEventDone     = "done"
+)
+
+const (
+	// sseHeartbeat mantiene abierta la conexión a través de proxies mientras no hay filas nuevas
+	sseHeartbeat = 15 * time.Second
+	// sseRetry es el tiempo en milisegundos que espera el navegador antes de reconectarse
+	sseRetry = 3000
+)
+
+// sseProgress es el resumen de avance que acompaña a cada tanda de filas
+type sseProgress struct {
+	Status    string `json:"status"`
+	Processed int    `json:"processed"`
+	Total     int    `json:"total"`
+	Ok        int    `json:"ok"`
+	Nok       int    `json:"nok"`
+	// Duplicates son las filas cuya dirección se repetía y reutilizaron un resultado anterior
+	Duplicates int `json:"duplicates"`
+	// Disputed son las filas en que los proveedores no coincidieron y Agreement las coincidencias
+	// de cada proveedor con el resultado, solo en modo consenso
+	Disputed  int                                 `json:"disputed,omitempty"`
+	Agreement map[string]domain.ProviderAgreement `json:"agreement,omitempty"`
+	Message   string                              `json:"message,omitempty"`
+}
+
+// writeEvent escribe un evento SSE; id vacío omite el campo para no mover el Last-Event-ID del cliente
+func writeEvent(w io.Writer, event, id string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+func writeHeartbeat(w io.Writer) error {
+	_, err := fmt.Fprintf(w, ": heartbeat %d\n\n", time.Now().Unix())
+	return err
+}
+
+// lastEventID retorna el índice de la última fila recibida por el cliente, o -1 si no hay.
+// EventSource lo envía en el header al reconectarse; lastEventId permite retomar en una conexión nueva.
+func lastEventID(r *http.Request) int {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 0 {
+		return -1
+	}
+	return id
+}
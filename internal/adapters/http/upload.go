@@ -0,0 +1,241 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"wemaps/internal/services"
+)
+
+// uploadCoordsHandler recibe un CSV o XLSX como multipart/form-data y lo procesa en el mismo pipeline que submitcoords.
+// Campos opcionales: report_name, address (composición de la dirección en JSON), coordinates (columnas
+// latitude y longitude en JSON para geocodificación inversa), min_precision, consensus (providers y meters en JSON),
+// header_row, delimiter y encoding (CSV) o sheet (XLSX); si no se envían se detectan desde el archivo.
+func (s *Server) uploadCoordsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+		return
+	}
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+	user, err := s.portalService.ValidateToken(token)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid token: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	fmt.Println("Usuario autenticado:", user.Alias)
+
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxUploadBytes())
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+
+	// Leer el archivo y los campos sin cargar el archivo completo en memoria
+	fields := make(map[string]string)
+	var path, checksum, fileName, extension string
+	// El archivo subido se elimina en cualquier error; si se crea la tarea, ella lo libera al terminar
+	started := false
+	defer func() {
+		if path != "" && !started {
+			services.ReleaseUpload(path)
+		}
+	}()
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			uploadError(w, err, "Invalid multipart body", http.StatusBadRequest)
+			return
+		}
+
+		if part.FormName() == "file" {
+			if path != "" {
+				http.Error(w, "Only one file per upload", http.StatusBadRequest)
+				return
+			}
+			fileName = part.FileName()
+			extension = strings.ToLower(filepath.Ext(fileName))
+			path, checksum, err = services.StoreUpload(part, extension)
+			if err != nil {
+				uploadError(w, err, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		} else if part.FormName() != "" {
+			value, _ := io.ReadAll(io.LimitReader(part, 8192))
+			fields[part.FormName()] = strings.TrimSpace(string(value))
+		}
+		part.Close()
+	}
+
+	if path == "" {
+		http.Error(w, "Missing file", http.StatusBadRequest)
+		return
+	}
+
+	var report services.CoordsReportRequest
+	if extension == ".xlsx" {
+		report, err = s.xlsxReport(path, checksum, fields)
+	} else {
+		report, err = s.csvReport(path, checksum, fields)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if report.ReportName == "" {
+		report.ReportName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	}
+
+	// La composición de la dirección se envía como JSON en el campo address
+	if address := fields["address"]; address != "" {
+		report.Address = &services.AddressMapping{}
+		if err := json.Unmarshal([]byte(address), report.Address); err != nil {
+			http.Error(w, "Invalid address mapping", http.StatusBadRequest)
+			return
+		}
+	}
+	if coordinates := fields["coordinates"]; coordinates != "" {
+		report.Coordinates = &services.CoordinatesMapping{}
+		if err := json.Unmarshal([]byte(coordinates), report.Coordinates); err != nil {
+			http.Error(w, "Invalid coordinates mapping", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := report.RowMapping().Validate(report.Columns); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid address mapping: %v", err), http.StatusBadRequest)
+		return
+	}
+	report.MinPrecision = fields["min_precision"]
+	if consensus := fields["consensus"]; consensus != "" {
+		report.Consensus = &services.ConsensusOptions{}
+		if err := json.Unmarshal([]byte(consensus), report.Consensus); err != nil {
+			http.Error(w, "Invalid consensus options", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := report.ValidateOptions(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	started = s.startReport(w, user.ID, report)
+}
+
+// csvReport arma el reporte desde el CSV subido, usando el formato detectado salvo que se indique otro
+func (s *Server) csvReport(path, checksum string, fields map[string]string) (services.CoordsReportRequest, error) {
+	format, err := services.SniffCSVFile(path)
+	if err != nil {
+		return services.CoordsReportRequest{}, fmt.Errorf("error reading file: %v", err)
+	}
+
+	if delimiter := fields["delimiter"]; delimiter != "" {
+		if format.Delimiter, err = delimiterField(delimiter); err != nil {
+			return services.CoordsReportRequest{}, err
+		}
+	}
+	if encoding := fields["encoding"]; encoding != "" {
+		format.Encoding = strings.ToLower(encoding)
+	}
+	if format.HeaderRow, err = headerRowField(fields, format.HeaderRow); err != nil {
+		return services.CoordsReportRequest{}, err
+	}
+
+	source, columns, err := services.NewCSVSource(path, checksum, format)
+	if err != nil {
+		return services.CoordsReportRequest{}, fmt.Errorf("invalid CSV file: %v", err)
+	}
+	if len(columns) == 0 || source.Rows == 0 {
+		return services.CoordsReportRequest{}, fmt.Errorf("CSV file has no rows")
+	}
+
+	return services.CoordsReportRequest{
+		ReportName: fields["report_name"],
+		Columns:    columns,
+		Source:     source,
+	}, nil
+}
+
+// xlsxReport arma el reporte desde la hoja elegida del libro Excel subido
+func (s *Server) xlsxReport(path, checksum string, fields map[string]string) (services.CoordsReportRequest, error) {
+	format, err := services.SniffXLSXFile(path, fields["sheet"])
+	if err != nil {
+		return services.CoordsReportRequest{}, err
+	}
+	if format.HeaderRow, err = headerRowField(fields, format.HeaderRow); err != nil {
+		return services.CoordsReportRequest{}, err
+	}
+
+	source, columns, err := services.NewXLSXSource(path, checksum, format)
+	if err != nil {
+		return services.CoordsReportRequest{}, fmt.Errorf("invalid XLSX file: %v", err)
+	}
+	if len(columns) == 0 || source.Rows == 0 {
+		return services.CoordsReportRequest{}, fmt.Errorf("sheet %q has no rows", format.Sheet)
+	}
+
+	return services.CoordsReportRequest{
+		ReportName: fields["report_name"],
+		Columns:    columns,
+		Source:     source,
+	}, nil
+}
+
+// uploadError responde 413 si la carga superó el tamaño máximo y status con message en otro caso
+func uploadError(w http.ResponseWriter, err error, message string, status int) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("Upload exceeds the maximum size of %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// delimiterField acepta "tab" o "\t" para el tabulador, de lo contrario un único carácter UTF-8
+func delimiterField(value string) (string, error) {
+	if value == "tab" || value == `\t` {
+		return "\t", nil
+	}
+	r, size := utf8.DecodeRuneInString(value)
+	if r == utf8.RuneError || size != len(value) {
+		return "", fmt.Errorf("invalid delimiter: %s", value)
+	}
+	return value, nil
+}
+
+// headerRowField retorna la fila de encabezado indicada por el usuario o la detectada
+func headerRowField(fields map[string]string, detected int) (int, error) {
+	headerRow := fields["header_row"]
+	if headerRow == "" {
+		return detected, nil
+	}
+	row, err := strconv.Atoi(headerRow)
+	if err != nil || row < 0 {
+		return 0, fmt.Errorf("invalid header_row: %s", headerRow)
+	}
+	return row, nil
+}
@@ -0,0 +1,45 @@
+package http
+
+import "testing"
+
+func TestDelimiterField(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: ";", want: ";"},
+		{value: "tab", want: "\t"},
+		{value: `\t`, want: "\t"},
+		{value: "¦", want: "¦"},
+		{value: "\xc2", wantErr: true},
+		{value: ";;", wantErr: true},
+		{value: "\ufeff;", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := delimiterField(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("delimiterField(%q) = %q, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestHeaderRowField(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "", want: 3},
+		{value: "0", want: 0},
+		{value: "5", want: 5},
+		{value: "-1", wantErr: true},
+		{value: "segunda", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := headerRowField(map[string]string{"header_row": tt.value}, 3)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("headerRowField(%q) = %d, %v", tt.value, got, err)
+		}
+	}
+}
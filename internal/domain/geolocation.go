@@ -8,6 +8,77 @@ type Geolocation struct {
 	Geocoder          string          `json:"geocoder" bson:"geocoder"`
 	Status            StatusGeoResult `json:"status" bson:"-"`
 	ResponseCoordsApi []interface{}   `json:"-" bson:"response_coors_api"`
+
+	// Confidence es la confianza del proveedor normalizada entre 0 y 1, 0 si no la informa
+	Confidence float64 `json:"confidence,omitempty" bson:"confidence,omitempty"`
+	// Components son las partes de la dirección informadas por el proveedor (calle, número, comuna, etc.)
+	Components map[string]string `json:"components,omitempty" bson:"components,omitempty"`
+	// Precision es el nivel de detalle del resultado, de rooftop a locality
+	Precision Precision `json:"precision,omitempty" bson:"precision,omitempty"`
+	// Alternatives son otros candidatos para una dirección ambigua, ordenados del mejor al peor
+	Alternatives []Geolocation `json:"alternatives,omitempty" bson:"alternatives,omitempty"`
+	// MatchScore es la coincidencia entre 0 y 1 de la dirección ingresada con la dirección del resultado
+	MatchScore float64 `json:"match_score,omitempty" bson:"match_score,omitempty"`
+	// Source es el nombre en la cadena de geocodificadores del proveedor que entregó el resultado
+	Source string `json:"source,omitempty" bson:"source,omitempty"`
+	// Consensus compara los resultados de varios proveedores cuando se geocodifica en modo consenso
+	Consensus *Consensus `json:"consensus,omitempty" bson:"consensus,omitempty"`
+}
+
+// Estados del modo consenso
+const (
+	// ConsensusConfirmed indica que todos los proveedores coinciden dentro de la distancia pedida
+	ConsensusConfirmed = "confirmed"
+	// ConsensusDisputed indica que algún proveedor entregó una ubicación distinta, que queda en Alternatives
+	ConsensusDisputed = "disputed"
+	// ConsensusSingle indica que solo un proveedor encontró la dirección y no hubo con qué comparar
+	ConsensusSingle = "single"
+)
+
+// Consensus es la comparación de los proveedores consultados para una dirección
+type Consensus struct {
+	Status string `json:"status" bson:"status"`
+	// Agreeing son los proveedores que coinciden con el resultado y Disagreeing los que no
+	Agreeing    []string `json:"agreeing" bson:"agreeing"`
+	Disagreeing []string `json:"disagreeing,omitempty" bson:"disagreeing,omitempty"`
+	// Distance es la mayor distancia en metros entre el resultado y los demás proveedores
+	Distance float64 `json:"distance" bson:"distance"`
+}
+
+// ProviderAgreement cuenta las direcciones en que un proveedor coincidió o no con el resultado del consenso
+type ProviderAgreement struct {
+	Agreed    int `json:"agreed"`
+	Disagreed int `json:"disagreed"`
+}
+
+// Precision es el nivel de detalle de un resultado de geocodificación
+type Precision string
+
+const (
+	PrecisionRooftop      Precision = "rooftop"
+	PrecisionInterpolated Precision = "interpolated"
+	PrecisionStreet       Precision = "street"
+	PrecisionLocality     Precision = "locality"
+)
+
+// Level ordena las precisiones de mayor a menor detalle; 0 si es desconocida
+func (p Precision) Level() int {
+	switch p {
+	case PrecisionRooftop:
+		return 4
+	case PrecisionInterpolated:
+		return 3
+	case PrecisionStreet:
+		return 2
+	case PrecisionLocality:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast indica si la precisión es igual o más detallada que min
+func (p Precision) AtLeast(min Precision) bool {
+	return p.Level() >= min.Level()
 }
 
 type StatusGeoResult struct {
@@ -16,4 +87,6 @@ type StatusGeoResult struct {
 	Nok    int  `json:"nok"`
 	Total  int  `json:"total"`
 	Result bool `json:"result"`
+	// ErrorKind clasifica por qué la fila no se geocodificó: transient, not_found, not_exact, auth_quota o unknown
+	ErrorKind string `json:"error_kind,omitempty"`
 }
@@ -1,5 +1,17 @@
 package domain
 
+import "time"
+
 type HealthStatus struct {
-	Status string `json:"status"`
+	Status    string           `json:"status"`
+	Geocoders []GeocoderHealth `json:"geocoders,omitempty"`
+}
+
+// GeocoderHealth es el estado del circuit breaker de un proveedor: closed, open o half-open
+type GeocoderHealth struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	LastError string     `json:"last_error,omitempty"`
+	RetryAt   *time.Time `json:"retry_at,omitempty"`
 }
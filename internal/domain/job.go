@@ -0,0 +1,31 @@
+package domain
+
+import "time"
+
+// Estados de una tarea de carga (ver "Carga ETL" en el README)
+const (
+	JobStatusInitial   = "initial"
+	JobStatusInProcess = "in process"
+	JobStatusError     = "error"
+	JobStatusFinish    = "finish"
+	JobStatusPaused    = "paused"
+	JobStatusCancelled = "cancelled"
+)
+
+type Job struct {
+	ID             int
+	IdTask         string
+	DefinitionHash string
+	Definition     string
+	SessionID      string
+	UserID         int
+	ReportID       int
+	ReportName     string
+	Status         string
+	RecordProcess  int
+	TotalRecords   int
+	IdError        string
+	Message        string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
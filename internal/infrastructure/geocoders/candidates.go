@@ -0,0 +1,42 @@
+package geocoders
+
+import (
+	"context"
+	"sort"
+	"wemaps/internal/domain"
+)
+
+// MaxCandidates es la cantidad de candidatos que se piden a cada proveedor
+const MaxCandidates = 5
+
+// CandidateGeocoder es un proveedor que además retorna los candidatos de una dirección, ordenados del mejor
+// al peor, con Confidence entre 0 y 1 y Precision, sin descartar los que no son exactos
+type CandidateGeocoder interface {
+	Candidates(ctx context.Context, address string) ([]domain.Geolocation, error)
+}
+
+// rankCandidates ordena por precisión y luego por confianza, conservando el orden del proveedor en los empates
+func rankCandidates(candidates []domain.Geolocation) []domain.Geolocation {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Precision.Level() != candidates[j].Precision.Level() {
+			return candidates[i].Precision.Level() > candidates[j].Precision.Level()
+		}
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates
+}
+
+// osmPrecision traduce la clase y el tipo de un resultado de OpenStreetMap (Nominatim, LocationIQ)
+func osmPrecision(class, placeType string) domain.Precision {
+	switch {
+	case placeType == "house" || placeType == "building" || placeType == "place" || class == "building":
+		return domain.PrecisionRooftop
+	case class == "highway":
+		return domain.PrecisionStreet
+	case class == "place" || class == "boundary" || class == "landuse":
+		return domain.PrecisionLocality
+	case class == "amenity" || class == "shop" || class == "office" || class == "tourism":
+		return domain.PrecisionRooftop
+	}
+	return domain.PrecisionLocality
+}
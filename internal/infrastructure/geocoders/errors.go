@@ -0,0 +1,76 @@
+package geocoders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ErrorKind clasifica por qué un proveedor no entregó coordenadas
+type ErrorKind string
+
+const (
+	// KindTransient son fallas de red, timeouts, HTTP 429/5xx o errores internos del proveedor; se reintentan
+	KindTransient ErrorKind = "transient"
+	// KindNotFound indica que el proveedor no encontró la dirección
+	KindNotFound ErrorKind = "not_found"
+	// KindNotExact indica que el resultado no cumple los criterios de exactitud
+	KindNotExact ErrorKind = "not_exact"
+	// KindAuthQuota son credenciales inválidas, acceso denegado o cuota agotada
+	KindAuthQuota ErrorKind = "auth_quota"
+	// KindUnknown es cualquier otro error, por ejemplo una respuesta que no se pudo interpretar
+	KindUnknown ErrorKind = "unknown"
+)
+
+// GeocodeError es un error de proveedor con su clasificación
+type GeocodeError struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *GeocodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *GeocodeError) Unwrap() error {
+	return e.Err
+}
+
+func classified(kind ErrorKind, format string, args ...interface{}) error {
+	return &GeocodeError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// statusError clasifica una respuesta HTTP sin éxito del proveedor
+func statusError(provider string, resp *http.Response) error {
+	kind := KindUnknown
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		kind = KindTransient
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusPaymentRequired || resp.StatusCode == http.StatusForbidden:
+		kind = KindAuthQuota
+	}
+	return classified(kind, "%s respondió HTTP %d", provider, resp.StatusCode)
+}
+
+// Classify retorna la clasificación del error; los errores de red sin clasificar se consideran transitorios
+func Classify(err error) ErrorKind {
+	var geocodeErr *GeocodeError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &geocodeErr):
+		return geocodeErr.Kind
+	case errors.Is(err, ErrNotFound):
+		return KindNotFound
+	case errors.Is(err, ErrNotExact):
+		return KindNotExact
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &netErr):
+		return KindTransient
+	default:
+		return KindUnknown
+	}
+}
@@ -0,0 +1,58 @@
+package geocoders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "sin error", err: nil, want: ""},
+		{name: "clasificado", err: classified(KindAuthQuota, "clave inválida"), want: KindAuthQuota},
+		{name: "clasificado envuelto", err: fmt.Errorf("nominatim: %w", classified(KindTransient, "HTTP 503")), want: KindTransient},
+		{name: "no encontrada", err: fmt.Errorf("google: %w", ErrNotFound), want: KindNotFound},
+		{name: "no exacta", err: ErrNotExact, want: KindNotExact},
+		{name: "timeout", err: fmt.Errorf("consulta: %w", context.DeadlineExceeded), want: KindTransient},
+		{name: "respuesta cortada", err: io.ErrUnexpectedEOF, want: KindTransient},
+		{name: "error de red", err: &net.DNSError{Err: "no such host", Name: "nominatim.openstreetmap.org"}, want: KindTransient},
+		{name: "otro error", err: errors.New("json inválido"), want: KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, se esperaba %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorKind
+	}{
+		{status: http.StatusTooManyRequests, want: KindTransient},
+		{status: http.StatusServiceUnavailable, want: KindTransient},
+		{status: http.StatusUnauthorized, want: KindAuthQuota},
+		{status: http.StatusPaymentRequired, want: KindAuthQuota},
+		{status: http.StatusForbidden, want: KindAuthQuota},
+		{status: http.StatusBadRequest, want: KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := statusError("google", &http.Response{StatusCode: tt.status})
+			if got := Classify(err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, se esperaba %q", err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package geocoders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wemaps/internal/domain"
+)
+
+// GeoapifyBaseURL es el endpoint de geocodificación de Geoapify
+const GeoapifyBaseURL = "https://api.geoapify.com/v1/geocode/search"
+
+// geoapifyPlaceTypes traduce result_type de Geoapify a los tipos de Nominatim para aplicar las mismas reglas.
+// amenity no se traduce: es un lugar de interés cercano, no el edificio de la dirección, y no es exacto.
+var geoapifyPlaceTypes = map[string]string{
+	"building": "building",
+}
+
+type GeoapifyGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+type geoapifyResponse struct {
+	Results []geoapifyResult `json:"results"`
+}
+
+type geoapifyResult struct {
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Formatted   string  `json:"formatted"`
+	ResultType  string  `json:"result_type"`
+	HouseNumber string  `json:"housenumber"`
+	Street      string  `json:"street"`
+	City        string  `json:"city"`
+	State       string  `json:"state"`
+	Postcode    string  `json:"postcode"`
+	CountryCode string  `json:"country_code"`
+	Rank        struct {
+		Confidence float64 `json:"confidence"`
+		MatchType  string  `json:"match_type"`
+	} `json:"rank"`
+}
+
+// NewGeoapifyGeocoder crea el geocodificador con las mismas reglas de exactitud que Nominatim
+func NewGeoapifyGeocoder(options Options) *GeoapifyGeocoder {
+	options = options.withDefaults(GeoapifyBaseURL, exactPlaceTypes...)
+	return &GeoapifyGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (g *GeoapifyGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	results, err := g.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := results[0]
+	placeType, ok := geoapifyPlaceTypes[result.ResultType]
+	if !ok {
+		placeType = result.ResultType
+	}
+	if !g.accept[placeType] {
+		return nil, fmt.Errorf("%w: tipo de resultado %q", ErrNotExact, result.ResultType)
+	}
+	return result.geolocation(), nil
+}
+
+// Candidates retorna los resultados de Geoapify ordenados, incluidos los de calle o localidad
+func (g *GeoapifyGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	results, err := g.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]domain.Geolocation, len(results))
+	for i, result := range results {
+		candidates[i] = *result.geolocation()
+	}
+	return rankCandidates(candidates), nil
+}
+
+// search consulta hasta limit resultados de la dirección
+func (g *GeoapifyGeocoder) search(ctx context.Context, address string, limit int) ([]geoapifyResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("text", address)
+	params.Add("apiKey", g.options.APIKey)
+	params.Add("format", "json")
+	params.Add("limit", strconv.Itoa(limit))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", g.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("Geoapify", resp)
+	}
+
+	var data geoapifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) == 0 {
+		return nil, ErrNotFound
+	}
+	return data.Results, nil
+}
+
+func (result geoapifyResult) geolocation() *domain.Geolocation {
+	precision := domain.PrecisionLocality
+	switch result.ResultType {
+	case "building":
+		precision = domain.PrecisionRooftop
+	case "street", "amenity":
+		precision = domain.PrecisionStreet
+	}
+	return &domain.Geolocation{
+		FormattedAddress: result.Formatted,
+		Latitude:         result.Lat,
+		Longitude:        result.Lon,
+		Geocoder:         "geoapify",
+		Confidence:       result.Rank.Confidence,
+		Precision:        precision,
+		Components: map[string]string{
+			"house_number": result.HouseNumber,
+			"road":         result.Street,
+			"city":         result.City,
+			"state":        result.State,
+			"postcode":     result.Postcode,
+			"country_code": result.CountryCode,
+		},
+		ResponseCoordsApi: []interface{}{result},
+	}
+}
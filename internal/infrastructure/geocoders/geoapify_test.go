@@ -0,0 +1,64 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestGeoapifyGeocode(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		file    string
+		wantErr ErrorKind
+	}{
+		{name: "resultado exacto", status: http.StatusOK, file: "geoapify_hit.json"},
+		{name: "sin resultados", status: http.StatusOK, file: "geoapify_empty.json", wantErr: KindNotFound},
+		{name: "lugar de interés no es exacto", status: http.StatusOK, file: "geoapify_amenity.json", wantErr: KindNotExact},
+		{name: "api key inválida", status: http.StatusUnauthorized, file: "provider_error.json", wantErr: KindAuthQuota},
+		{name: "límite de consultas", status: http.StatusTooManyRequests, file: "provider_error.json", wantErr: KindTransient},
+		{name: "error del servidor", status: http.StatusInternalServerError, file: "provider_error.json", wantErr: KindTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, tt.status, tt.file)
+			geocoder := NewGeoapifyGeocoder(Options{BaseURL: server.URL, APIKey: "clave-geoapify"})
+
+			geo, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234, Providencia")
+			if kind := Classify(err); kind != tt.wantErr {
+				t.Fatalf("error %v clasificado como %q, se esperaba %q", err, kind, tt.wantErr)
+			}
+			if key := server.request.URL.Query().Get("apiKey"); key != "clave-geoapify" {
+				t.Errorf("apiKey = %q", key)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if geo.FormattedAddress != "Avenida Providencia 1234, 7500000 Providencia, Chile" {
+				t.Errorf("FormattedAddress = %q", geo.FormattedAddress)
+			}
+			if geo.Latitude != -33.4263 || geo.Longitude != -70.6109 {
+				t.Errorf("coordenadas = %v, %v", geo.Latitude, geo.Longitude)
+			}
+			if geo.Precision != domain.PrecisionRooftop || geo.Confidence != 0.95 {
+				t.Errorf("precisión %q, confianza %v", geo.Precision, geo.Confidence)
+			}
+		})
+	}
+}
+
+func TestGeoapifyCandidates(t *testing.T) {
+	server := newStandIn(t, http.StatusOK, "geoapify_amenity.json")
+	geocoder := NewGeoapifyGeocoder(Options{BaseURL: server.URL, APIKey: "clave-geoapify"})
+
+	candidates, err := geocoder.Candidates(context.Background(), "Avenida Providencia 1234, Providencia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// El lugar de interés queda como resultado de calle, después del edificio
+	if len(candidates) != 2 || candidates[0].Precision != domain.PrecisionRooftop || candidates[1].Precision != domain.PrecisionStreet {
+		t.Errorf("candidatos = %+v", candidates)
+	}
+}
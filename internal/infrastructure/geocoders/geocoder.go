@@ -1,7 +1,77 @@
 package geocoders
 
-import "wemaps/internal/domain"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+	"wemaps/internal/domain"
+)
 
+// DefaultTimeout es el tiempo máximo de una consulta a un proveedor si no se configura otro
+const DefaultTimeout = 10 * time.Second
+
+// ErrNotFound indica que el proveedor respondió correctamente pero no encontró la dirección
+var ErrNotFound = errors.New("no se encontraron resultados")
+
+// Geocoder geocodifica una dirección; la consulta se abandona al cancelarse ctx
 type Geocoder interface {
-	Geocode(address string) (*domain.Geolocation, error)
+	Geocode(ctx context.Context, address string) (*domain.Geolocation, error)
+}
+
+// Options configura un proveedor; los campos vacíos usan los valores por defecto de cada proveedor
+type Options struct {
+	APIKey  string
+	BaseURL string
+	// ReverseURL es el endpoint de geocodificación inversa de los proveedores que lo separan del de búsqueda
+	ReverseURL string
+	// Accept son los tipos o niveles de precisión del resultado que se aceptan como exactos
+	Accept []string
+	Client *http.Client
+	// Timeout es el tiempo máximo de cada consulta
+	Timeout time.Duration
+}
+
+// withDefaults completa las opciones vacías con los valores del proveedor
+func (o Options) withDefaults(baseURL string, accept ...string) Options {
+	if o.BaseURL == "" {
+		o.BaseURL = baseURL
+	}
+	if len(o.Accept) == 0 {
+		o.Accept = accept
+	}
+	if o.Client == nil {
+		// El timeout de cada consulta lo define el contexto
+		o.Client = &http.Client{}
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	return o
+}
+
+func acceptSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// BatchGeocoder es un proveedor que además geocodifica varias direcciones en una sola consulta
+type BatchGeocoder interface {
+	Geocoder
+	// GeocodeBatch retorna un resultado por dirección en el mismo orden; el error indica que falló la consulta completa
+	GeocodeBatch(ctx context.Context, addresses []string) ([]BatchResult, error)
+}
+
+// BatchResult es el resultado de una dirección dentro de una consulta por lote
+type BatchResult struct {
+	Geo *domain.Geolocation
+	Err error
+}
+
+// ReverseGeocoder es un proveedor que además obtiene la dirección de un par de coordenadas
+type ReverseGeocoder interface {
+	ReverseGeocode(ctx context.Context, lat, lon float64) (*domain.Geolocation, error)
 }
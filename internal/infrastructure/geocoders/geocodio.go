@@ -0,0 +1,181 @@
+package geocoders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wemaps/internal/domain"
+)
+
+// GeocodioBaseURL es el endpoint de geocodificación de Geocodio, acepta GET para una dirección y POST para un lote
+const GeocodioBaseURL = "https://api.geocod.io/v1.7/geocode"
+
+type GeocodioGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+type geocodioResponse struct {
+	Results []geocodioResult `json:"results"`
+	Error   string           `json:"error"`
+}
+
+type geocodioBatchResponse struct {
+	Results []struct {
+		Query    string           `json:"query"`
+		Response geocodioResponse `json:"response"`
+	} `json:"results"`
+}
+
+type geocodioResult struct {
+	FormattedAddress  string                 `json:"formatted_address"`
+	AddressComponents map[string]interface{} `json:"address_components"`
+	Location          struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"location"`
+	Accuracy     float64 `json:"accuracy"`
+	AccuracyType string  `json:"accuracy_type"`
+}
+
+// NewGeocodioGeocoder crea el geocodificador; por defecto acepta precisión rooftop, point y range_interpolation
+func NewGeocodioGeocoder(options Options) *GeocodioGeocoder {
+	options = options.withDefaults(GeocodioBaseURL, "rooftop", "point", "range_interpolation")
+	return &GeocodioGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (g *GeocodioGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+
+	params := g.params(1)
+	params.Add("q", address)
+	req, err := http.NewRequestWithContext(ctx, "GET", g.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data geocodioResponse
+	if err := g.do(req, &data); err != nil {
+		return nil, err
+	}
+	return g.geolocation(data)
+}
+
+// Candidates retorna los resultados de Geocodio ordenados, incluidos los de calle o localidad
+func (g *GeocodioGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+
+	params := g.params(MaxCandidates)
+	params.Add("q", address)
+	req, err := http.NewRequestWithContext(ctx, "GET", g.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data geocodioResponse
+	if err := g.do(req, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) == 0 {
+		return nil, ErrNotFound
+	}
+	candidates := make([]domain.Geolocation, len(data.Results))
+	for i, result := range data.Results {
+		candidates[i] = *result.geolocation()
+	}
+	return rankCandidates(candidates), nil
+}
+
+// GeocodeBatch envía todas las direcciones en un solo POST; Geocodio responde en el mismo orden
+func (g *GeocodioGeocoder) GeocodeBatch(ctx context.Context, addresses []string) ([]BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(addresses)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", g.options.BaseURL+"?"+g.params(1).Encode(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var data geocodioBatchResponse
+	if err := g.do(req, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) != len(addresses) {
+		return nil, fmt.Errorf("Geocodio retornó %d resultados para %d direcciones", len(data.Results), len(addresses))
+	}
+
+	results := make([]BatchResult, len(addresses))
+	for i, result := range data.Results {
+		results[i].Geo, results[i].Err = g.geolocation(result.Response)
+	}
+	return results, nil
+}
+
+func (g *GeocodioGeocoder) params(limit int) url.Values {
+	params := url.Values{}
+	params.Add("api_key", g.options.APIKey)
+	params.Add("limit", strconv.Itoa(limit))
+	return params
+}
+
+func (g *GeocodioGeocoder) do(req *http.Request, data interface{}) error {
+	resp, err := g.options.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Geocodio responde 422 cuando no puede interpretar la dirección
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError("Geocodio", resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(data)
+}
+
+// geolocation toma el primer resultado si su precisión es aceptada
+func (g *GeocodioGeocoder) geolocation(data geocodioResponse) (*domain.Geolocation, error) {
+	if len(data.Results) == 0 {
+		return nil, ErrNotFound
+	}
+
+	result := data.Results[0]
+	if !g.accept[result.AccuracyType] {
+		return nil, fmt.Errorf("%w: precisión %q", ErrNotExact, result.AccuracyType)
+	}
+	return result.geolocation(), nil
+}
+
+func (result geocodioResult) geolocation() *domain.Geolocation {
+	precision := domain.PrecisionLocality
+	switch result.AccuracyType {
+	case "rooftop", "point":
+		precision = domain.PrecisionRooftop
+	case "range_interpolation", "nearest_rooftop_match":
+		precision = domain.PrecisionInterpolated
+	case "street_center", "intersection":
+		precision = domain.PrecisionStreet
+	}
+	return &domain.Geolocation{
+		FormattedAddress:  result.FormattedAddress,
+		Latitude:          result.Location.Lat,
+		Longitude:         result.Location.Lng,
+		Geocoder:          "geocodio",
+		Confidence:        result.Accuracy,
+		Precision:         precision,
+		Components:        stringComponents(result.AddressComponents),
+		ResponseCoordsApi: []interface{}{result},
+	}
+}
@@ -0,0 +1,57 @@
+package geocoders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+)
+
+// geoJSONResponse es la respuesta GeoJSON de Photon y Pelias
+type geoJSONResponse struct {
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Geometry struct {
+		// Coordinates viene en orden longitud, latitud
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// point retorna latitud y longitud del feature; ok es false si no trae geometría
+func (f geoJSONFeature) point() (lat, lon float64, ok bool) {
+	if len(f.Geometry.Coordinates) < 2 {
+		return 0, 0, false
+	}
+	return f.Geometry.Coordinates[1], f.Geometry.Coordinates[0], true
+}
+
+// searchGeoJSON consulta un endpoint de búsqueda que responde GeoJSON y retorna sus features
+func searchGeoJSON(ctx context.Context, options Options, provider string, params url.Values) ([]geoJSONFeature, error) {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(provider, resp)
+	}
+
+	var data geoJSONResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data.Features) == 0 {
+		return nil, ErrNotFound
+	}
+	return data.Features, nil
+}
@@ -1,39 +1,103 @@
 package geocoders
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
 	"wemaps/internal/domain"
 )
 
+// GoogleBaseURL es el endpoint de la API de geocodificación de Google
+const GoogleBaseURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
 type GoogleGeocoder struct {
-	apiKey string
+	options Options
+	accept  map[string]bool
 }
 
-func NewGoogleGeocoder() *GoogleGeocoder {
-	if os.Getenv("GOOGLE_API_KEY") == "" {
-		fmt.Print("Api de google setea")
-		return &GoogleGeocoder{apiKey: "NO EXISTE GOOGLE API KEY"}
-	} else {
-		return &GoogleGeocoder{apiKey: os.Getenv("GOOGLE_API_KEY")}
-	}
+// NewGoogleGeocoder crea el geocodificador; por defecto acepta los location_type ROOFTOP y RANGE_INTERPOLATED
+func NewGoogleGeocoder(options Options) *GoogleGeocoder {
+	options = options.withDefaults(GoogleBaseURL, "ROOFTOP", "RANGE_INTERPOLATED")
+	return &GoogleGeocoder{options: options, accept: acceptSet(options.Accept)}
 }
 
-func (g *GoogleGeocoder) Geocode(address string) (*domain.Geolocation, error) {
+func (g *GoogleGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
 	// Configurar los parámetros de la consulta
 	params := url.Values{}
 	params.Add("address", address)
-	params.Add("key", g.apiKey)
+	return g.query(ctx, params)
+}
+
+// ReverseGeocode busca la dirección de las coordenadas con los mismos location_type aceptados que Geocode
+func (g *GoogleGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*domain.Geolocation, error) {
+	params := url.Values{}
+	params.Add("latlng", strconv.FormatFloat(lat, 'f', -1, 64)+","+strconv.FormatFloat(lon, 'f', -1, 64))
+	return g.query(ctx, params)
+}
+
+// query consulta la API con params y retorna el primer resultado con location_type aceptado
+func (g *GoogleGeocoder) query(ctx context.Context, params url.Values) (*domain.Geolocation, error) {
+	results, err := g.results(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filtrar resultados por location_type
+	for _, res := range results {
+		geo, locationType, ok := googleGeolocation(res, results)
+		// Verificar si el location_type es válido GEOMETRIC_CENTER si no tiene numero
+		if ok && g.accept[locationType] {
+			return geo, nil
+		}
+	}
+
+	// Si no hay resultados con location_type válido
+	return nil, fmt.Errorf("%w: no se encontraron resultados con location_type válido %v", ErrNotExact, g.options.Accept)
+}
+
+// Candidates retorna todos los resultados de Google ordenados, incluidos los que no son ROOFTOP
+func (g *GoogleGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	params := url.Values{}
+	params.Add("address", address)
+	results, err := g.results(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.Geolocation
+	for _, res := range results {
+		if geo, _, ok := googleGeolocation(res, results); ok {
+			candidates = append(candidates, *geo)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	return rankCandidates(candidates), nil
+}
+
+// results ejecuta la consulta y retorna los resultados de una respuesta OK
+func (g *GoogleGeocoder) results(ctx context.Context, params url.Values) ([]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.options.Timeout)
+	defer cancel()
+	params.Add("key", g.options.APIKey)
 
 	// Ejecutar la solicitud HTTP
-	resp, err := http.Get("https://maps.googleapis.com/maps/api/geocode/json?" + params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", g.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.options.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("Google", resp)
+	}
 
 	// Decodificar la respuesta JSON
 	var data map[string]interface{}
@@ -42,53 +106,90 @@ func (g *GoogleGeocoder) Geocode(address string) (*domain.Geolocation, error) {
 	}
 
 	// Verificar el estado de la respuesta
-	if data["status"] != "OK" {
+	switch data["status"] {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, ErrNotFound
+	case "UNKNOWN_ERROR":
+		return nil, classified(KindTransient, "error en la respuesta de Google: %s", data["status"])
+	case "OVER_QUERY_LIMIT", "OVER_DAILY_LIMIT", "REQUEST_DENIED":
+		return nil, classified(KindAuthQuota, "error en la respuesta de Google: %s", data["status"])
+	default:
 		return nil, fmt.Errorf("error en la respuesta de Google: %s", data["status"])
 	}
 
 	// Obtener los resultados
 	results, ok := data["results"].([]interface{})
 	if !ok || len(results) == 0 {
-		return nil, fmt.Errorf("no se encontraron resultados")
+		return nil, ErrNotFound
 	}
+	return results, nil
+}
 
-	// Filtrar resultados por location_type
-	for _, res := range results {
-		result := res.(map[string]interface{})
-		geometry, ok := result["geometry"].(map[string]interface{})
-		if !ok {
-			continue
-		}
-		locationType, ok := geometry["location_type"].(string)
-		if !ok {
-			continue
-		}
+// googleGeolocation convierte un resultado y retorna su location_type; ok es false si no trae ubicación
+func googleGeolocation(res interface{}, results []interface{}) (*domain.Geolocation, string, bool) {
+	result, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, "", false
+	}
+	geometry, ok := result["geometry"].(map[string]interface{})
+	if !ok {
+		return nil, "", false
+	}
+	locationType, ok := geometry["location_type"].(string)
+	if !ok {
+		return nil, "", false
+	}
+	location, ok := geometry["location"].(map[string]interface{})
+	if !ok {
+		return nil, "", false
+	}
+	lat, ok := location["lat"].(float64)
+	if !ok {
+		return nil, "", false
+	}
+	lng, ok := location["lng"].(float64)
+	if !ok {
+		return nil, "", false
+	}
+	formatted, _ := result["formatted_address"].(string)
+	partial, _ := result["partial_match"].(bool)
 
-		// Verificar si el location_type es válido GEOMETRIC_CENTER si no tiene numero
-		if locationType == "ROOFTOP" || locationType == "RANGE_INTERPOLATED" {
-			location, ok := geometry["location"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			lat, ok := location["lat"].(float64)
-			if !ok {
-				continue
-			}
-			lng, ok := location["lng"].(float64)
-			if !ok {
-				continue
-			}
+	precision, confidence := googlePrecision(locationType, result["types"])
+	if partial {
+		// Google encontró la dirección solo en parte
+		confidence *= 0.8
+	}
+	return &domain.Geolocation{
+		FormattedAddress:  formatted,
+		Latitude:          lat,
+		Longitude:         lng,
+		Geocoder:          "google",
+		Confidence:        confidence,
+		Precision:         precision,
+		ResponseCoordsApi: results,
+	}, locationType, true
+}
 
-			return &domain.Geolocation{
-				FormattedAddress:  result["formatted_address"].(string),
-				Latitude:          lat,
-				Longitude:         lng,
-				Geocoder:          "google",
-				ResponseCoordsApi: results,
-			}, nil
+// googlePrecision traduce location_type; Google no informa confianza, se estima según la precisión
+func googlePrecision(locationType string, types interface{}) (domain.Precision, float64) {
+	switch locationType {
+	case "ROOFTOP":
+		return domain.PrecisionRooftop, 1
+	case "RANGE_INTERPOLATED":
+		return domain.PrecisionInterpolated, 0.8
+	case "GEOMETRIC_CENTER":
+		// El centro de un edificio o de una calle según el tipo del resultado
+		list, _ := types.([]interface{})
+		for _, t := range list {
+			switch t {
+			case "premise", "subpremise", "establishment":
+				return domain.PrecisionRooftop, 0.7
+			case "route":
+				return domain.PrecisionStreet, 0.5
+			}
 		}
+		return domain.PrecisionLocality, 0.4
 	}
-
-	// Si no hay resultados con location_type válido
-	return nil, fmt.Errorf("no se encontraron resultados con location_type válido (ROOFTOP o RANGE_INTERPOLATED)")
+	return domain.PrecisionLocality, 0.3
 }
@@ -0,0 +1,129 @@
+package geocoders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wemaps/internal/domain"
+)
+
+// LocationIQBaseURL es el endpoint de búsqueda de LocationIQ, compatible con Nominatim
+const LocationIQBaseURL = "https://us1.locationiq.com/v1/search"
+
+type LocationIQGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+type locationIQPlace struct {
+	Lat         string            `json:"lat"`
+	Lon         string            `json:"lon"`
+	DisplayName string            `json:"display_name"`
+	Class       string            `json:"class"`
+	Type        string            `json:"type"`
+	Importance  float64           `json:"importance"`
+	Address     map[string]string `json:"address"`
+}
+
+// NewLocationIQGeocoder crea el geocodificador con las mismas reglas de exactitud que Nominatim
+func NewLocationIQGeocoder(options Options) *LocationIQGeocoder {
+	options = options.withDefaults(LocationIQBaseURL, exactPlaceTypes...)
+	return &LocationIQGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (l *LocationIQGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	data, err := l.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := data[0]
+	if !l.accept[result.Type] {
+		return nil, fmt.Errorf("%w: tipo de resultado %q", ErrNotExact, result.Type)
+	}
+	return result.geolocation()
+}
+
+// Candidates retorna los resultados de LocationIQ ordenados, incluidos los de calle o localidad
+func (l *LocationIQGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	data, err := l.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.Geolocation
+	for _, result := range data {
+		if geo, err := result.geolocation(); err == nil {
+			candidates = append(candidates, *geo)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	return rankCandidates(candidates), nil
+}
+
+// search consulta hasta limit resultados de la dirección
+func (l *LocationIQGeocoder) search(ctx context.Context, address string, limit int) ([]locationIQPlace, error) {
+	ctx, cancel := context.WithTimeout(ctx, l.options.Timeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("key", l.options.APIKey)
+	params.Add("q", address)
+	params.Add("format", "json")
+	params.Add("limit", strconv.Itoa(limit))
+	params.Add("addressdetails", "1")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", l.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// LocationIQ responde 404 cuando no encuentra la dirección
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("LocationIQ", resp)
+	}
+
+	var data []locationIQPlace
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// geolocation convierte el resultado con la precisión según su clase y tipo de OpenStreetMap
+func (result locationIQPlace) geolocation() (*domain.Geolocation, error) {
+	lat, err := strconv.ParseFloat(result.Lat, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error al parsear latitud: %v", err)
+	}
+	lon, err := strconv.ParseFloat(result.Lon, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error al parsear longitud: %v", err)
+	}
+
+	return &domain.Geolocation{
+		FormattedAddress:  result.DisplayName,
+		Latitude:          lat,
+		Longitude:         lon,
+		Geocoder:          "locationiq",
+		Confidence:        result.Importance,
+		Precision:         osmPrecision(result.Class, result.Type),
+		Components:        result.Address,
+		ResponseCoordsApi: []interface{}{result},
+	}, nil
+}
@@ -0,0 +1,49 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestLocationIQGeocode(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		file    string
+		wantErr ErrorKind
+	}{
+		{name: "resultado exacto", status: http.StatusOK, file: "locationiq_hit.json"},
+		{name: "resultado de calle", status: http.StatusOK, file: "locationiq_street.json", wantErr: KindNotExact},
+		{name: "sin resultados", status: http.StatusNotFound, file: "locationiq_not_found.json", wantErr: KindNotFound},
+		{name: "api key inválida", status: http.StatusUnauthorized, file: "provider_error.json", wantErr: KindAuthQuota},
+		{name: "límite de consultas", status: http.StatusTooManyRequests, file: "provider_error.json", wantErr: KindTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, tt.status, tt.file)
+			geocoder := NewLocationIQGeocoder(Options{BaseURL: server.URL, APIKey: "clave-locationiq"})
+
+			geo, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234, Providencia")
+			if kind := Classify(err); kind != tt.wantErr {
+				t.Fatalf("error %v clasificado como %q, se esperaba %q", err, kind, tt.wantErr)
+			}
+			if key := server.request.URL.Query().Get("key"); key != "clave-locationiq" {
+				t.Errorf("key = %q", key)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if geo.Latitude != -33.4263 || geo.Longitude != -70.6109 {
+				t.Errorf("coordenadas = %v, %v", geo.Latitude, geo.Longitude)
+			}
+			if geo.Precision != domain.PrecisionRooftop || geo.Geocoder != "locationiq" {
+				t.Errorf("precisión %q, geocodificador %q", geo.Precision, geo.Geocoder)
+			}
+			if geo.Components["house_number"] != "1234" {
+				t.Errorf("componentes = %v", geo.Components)
+			}
+		})
+	}
+}
@@ -1,41 +1,105 @@
 package geocoders
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"path"
 	"strconv"
+	"strings"
 	"wemaps/internal/domain"
 )
 
+// NominatimBaseURL es el endpoint de búsqueda del Nominatim público; una instancia propia se configura con BaseURL
+const NominatimBaseURL = "https://nominatim.openstreetmap.org/search"
+
+// exactPlaceTypes son los tipos de resultado con precisión de edificio o casa que se aceptan por defecto
+// en Nominatim y en los proveedores basados en OpenStreetMap
+var exactPlaceTypes = []string{"building", "place", "house"}
+
 // ErrNotExact se usa cuando el resultado no cumple con los criterios de exactitud
 var ErrNotExact = fmt.Errorf("resultado no exacto")
 
-type NominatimGeocoder struct{}
+type NominatimGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+// NewNominatimGeocoder crea el geocodificador; por defecto acepta resultados de tipo building, place y house.
+// Sin ReverseURL se usa el endpoint reverse junto al de búsqueda de la misma instancia.
+func NewNominatimGeocoder(options Options) *NominatimGeocoder {
+	options = options.withDefaults(NominatimBaseURL, exactPlaceTypes...)
+	if options.ReverseURL == "" {
+		options.ReverseURL = nominatimReverseURL(options.BaseURL)
+	}
+	return &NominatimGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+// nominatimReverseURL reemplaza el último segmento de la ruta de búsqueda por reverse, conservando
+// la extensión de las instalaciones que usan search.php
+func nominatimReverseURL(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	dir, file := path.Split(strings.TrimSuffix(u.Path, "/"))
+	u.Path = dir + "reverse" + path.Ext(file)
+	return u.String()
+}
+
+func (n *NominatimGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	data, err := n.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+	return n.geolocation(data[0], data)
+}
+
+// Candidates retorna los resultados de Nominatim ordenados, incluidos los de calle o localidad
+func (n *NominatimGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	data, err := n.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
 
-func NewNominatimGeocoder() *NominatimGeocoder {
-	return &NominatimGeocoder{}
+	var candidates []domain.Geolocation
+	for _, result := range data {
+		if geo, err := n.convert(result, data); err == nil {
+			candidates = append(candidates, *geo)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	return rankCandidates(candidates), nil
 }
 
-func (n *NominatimGeocoder) Geocode(address string) (*domain.Geolocation, error) {
+// search consulta hasta limit resultados de la dirección
+func (n *NominatimGeocoder) search(ctx context.Context, address string, limit int) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.options.Timeout)
+	defer cancel()
+
 	params := url.Values{}
 	params.Add("q", address)
 	params.Add("format", "json")
-	params.Add("limit", "1")
+	params.Add("limit", strconv.Itoa(limit))
 
-	req, err := http.NewRequest("GET", "https://nominatim.openstreetmap.org/search?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", n.options.BaseURL+"?"+params.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Add("User-Agent", "WeMaps/1.0 (contacto@wemaps.com)") // Requerido por Nominatim
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := n.options.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("Nominatim", resp)
+	}
 
 	var data []map[string]interface{}
 	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
@@ -43,34 +107,78 @@ func (n *NominatimGeocoder) Geocode(address string) (*domain.Geolocation, error)
 	}
 
 	if len(data) == 0 {
-		return nil, fmt.Errorf("no se encontraron resultados")
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// ReverseGeocode busca la dirección más cercana a nivel de edificio en el endpoint reverse
+func (n *NominatimGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*domain.Geolocation, error) {
+	ctx, cancel := context.WithTimeout(ctx, n.options.Timeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Add("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	params.Add("format", "json")
+	params.Add("zoom", "18")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", n.options.ReverseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("User-Agent", "WeMaps/1.0 (contacto@wemaps.com)") // Requerido por Nominatim
+
+	resp, err := n.options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("Nominatim", resp)
 	}
 
-	result := data[0]
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	// Sin resultados Nominatim responde {"error": "Unable to geocode"}
+	if _, failed := result["error"]; failed {
+		return nil, ErrNotFound
+	}
+	return n.geolocation(result, []map[string]interface{}{result})
+}
 
+// geolocation convierte un resultado de Nominatim si su categoría es aceptada
+func (n *NominatimGeocoder) geolocation(result map[string]interface{}, data []map[string]interface{}) (*domain.Geolocation, error) {
 	category, ok := result["type"].(string)
 	if !ok {
 		return nil, fmt.Errorf("no se pudo determinar la categoría del resultado")
 	}
 
-	exactCategories := map[string]bool{
-		"building": true,
-		"place":    true,
-		"house":    true,
-	}
-
-	if !exactCategories[category] {
+	if !n.accept[category] {
 		return nil, ErrNotExact
 	}
+	return n.convert(result, data)
+}
 
-	lat, err := strconv.ParseFloat(result["lat"].(string), 64)
+// convert arma la geolocalización de un resultado con la precisión según su clase y tipo.
+// Un resultado sin coordenadas o dirección válidas se rechaza con error en vez de detener al worker.
+func (n *NominatimGeocoder) convert(result map[string]interface{}, data []map[string]interface{}) (*domain.Geolocation, error) {
+	latValue, _ := result["lat"].(string)
+	lat, err := strconv.ParseFloat(latValue, 64)
 	if err != nil {
 		return nil, fmt.Errorf("error al parsear latitud: %v", err)
 	}
-	lon, err := strconv.ParseFloat(result["lon"].(string), 64)
+	lonValue, _ := result["lon"].(string)
+	lon, err := strconv.ParseFloat(lonValue, 64)
 	if err != nil {
 		return nil, fmt.Errorf("error al parsear longitud: %v", err)
 	}
+	displayName, ok := result["display_name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("el resultado no trae display_name")
+	}
 
 	responseCoordsApiJSON, err := json.Marshal(data)
 	if err != nil {
@@ -81,11 +189,17 @@ func (n *NominatimGeocoder) Geocode(address string) (*domain.Geolocation, error)
 	if err := json.Unmarshal(responseCoordsApiJSON, &responseCoordsApi); err != nil {
 		return nil, fmt.Errorf("error unmarshaling response JSON: %w", err)
 	}
+	class, _ := result["class"].(string)
+	placeType, _ := result["type"].(string)
+	// Nominatim no informa confianza, importance (0 a 1) es la relevancia del lugar
+	importance, _ := result["importance"].(float64)
 	return &domain.Geolocation{
-		FormattedAddress:  result["display_name"].(string),
+		FormattedAddress:  displayName,
 		Latitude:          lat,
 		Longitude:         lon,
 		Geocoder:          "nominatim",
+		Confidence:        importance,
+		Precision:         osmPrecision(class, placeType),
 		ResponseCoordsApi: responseCoordsApi,
 	}, nil
 }
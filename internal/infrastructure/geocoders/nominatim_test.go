@@ -0,0 +1,76 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestNominatimMalformedResults(t *testing.T) {
+	t.Run("candidatos", func(t *testing.T) {
+		server := newStandIn(t, http.StatusOK, "nominatim_malformed.json")
+		geocoder := NewNominatimGeocoder(Options{BaseURL: server.URL + "/search"})
+
+		// Se descartan el candidato con latitud numérica y el sin display_name
+		candidates, err := geocoder.Candidates(context.Background(), "Avenida Providencia 1234")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(candidates) != 1 || candidates[0].Precision != domain.PrecisionStreet {
+			t.Errorf("candidatos = %+v", candidates)
+		}
+	})
+
+	t.Run("geocode", func(t *testing.T) {
+		server := newStandIn(t, http.StatusOK, "nominatim_malformed.json")
+		geocoder := NewNominatimGeocoder(Options{BaseURL: server.URL + "/search"})
+
+		if _, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234"); err == nil {
+			t.Error("se esperaba error para un resultado sin latitud válida")
+		}
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		server := newStandIn(t, http.StatusOK, "nominatim_reverse_malformed.json")
+		geocoder := NewNominatimGeocoder(Options{BaseURL: server.URL + "/search"})
+
+		if _, err := geocoder.ReverseGeocode(context.Background(), -33.4263, -70.6109); err == nil {
+			t.Error("se esperaba error para un resultado sin longitud")
+		}
+		if server.request.URL.Path != "/reverse" {
+			t.Errorf("ruta = %q", server.request.URL.Path)
+		}
+	})
+}
+
+func TestNominatimReverseURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{name: "instancia pública", options: Options{}, want: "https://nominatim.openstreetmap.org/reverse"},
+		{name: "instancia propia bajo una ruta", options: Options{BaseURL: "http://geo.local/nominatim/search"}, want: "http://geo.local/nominatim/reverse"},
+		{name: "search.php", options: Options{BaseURL: "http://geo.local/search.php"}, want: "http://geo.local/reverse.php"},
+		{name: "ruta con barra final", options: Options{BaseURL: "http://geo.local/search/"}, want: "http://geo.local/reverse"},
+		{name: "explícita", options: Options{BaseURL: "http://geo.local/search", ReverseURL: "http://reverse.local/v1/reverse"}, want: "http://reverse.local/v1/reverse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewNominatimGeocoder(tt.options).options.ReverseURL; got != tt.want {
+				t.Errorf("ReverseURL = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("consulta la url explícita", func(t *testing.T) {
+		server := newStandIn(t, http.StatusOK, "nominatim_reverse_malformed.json")
+		geocoder := NewNominatimGeocoder(Options{BaseURL: "http://geo.local/search", ReverseURL: server.URL + "/osm/reverse"})
+
+		geocoder.ReverseGeocode(context.Background(), -33.4263, -70.6109)
+		if server.request == nil || server.request.URL.Path != "/osm/reverse" {
+			t.Errorf("no se consultó %s/osm/reverse", server.URL)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package geocoders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"wemaps/internal/domain"
+)
+
+// OpenCageBaseURL es el endpoint de geocodificación de OpenCage
+const OpenCageBaseURL = "https://api.opencagedata.com/geocode/v1/json"
+
+type OpenCageGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+type openCageResponse struct {
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+	Results []openCageResult `json:"results"`
+}
+
+type openCageResult struct {
+	Confidence int                    `json:"confidence"`
+	Formatted  string                 `json:"formatted"`
+	Components map[string]interface{} `json:"components"`
+	Geometry   struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	} `json:"geometry"`
+}
+
+// NewOpenCageGeocoder crea el geocodificador; por defecto acepta resultados con _type building o house
+func NewOpenCageGeocoder(options Options) *OpenCageGeocoder {
+	options = options.withDefaults(OpenCageBaseURL, "building", "house")
+	return &OpenCageGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (o *OpenCageGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	results, err := o.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	geo := results[0].geolocation()
+	if !o.accept[geo.Components["_type"]] {
+		return nil, fmt.Errorf("%w: tipo de resultado %q", ErrNotExact, geo.Components["_type"])
+	}
+	return geo, nil
+}
+
+// Candidates retorna los resultados de OpenCage ordenados, incluidos los de calle o localidad
+func (o *OpenCageGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	results, err := o.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]domain.Geolocation, len(results))
+	for i, result := range results {
+		candidates[i] = *result.geolocation()
+	}
+	return rankCandidates(candidates), nil
+}
+
+// search consulta hasta limit resultados de la dirección
+func (o *OpenCageGeocoder) search(ctx context.Context, address string, limit int) ([]openCageResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, o.options.Timeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Add("q", address)
+	params.Add("key", o.options.APIKey)
+	params.Add("limit", strconv.Itoa(limit))
+	params.Add("no_annotations", "1")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", o.options.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := o.options.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError("OpenCage", resp)
+	}
+
+	var data openCageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+	if len(data.Results) == 0 {
+		return nil, ErrNotFound
+	}
+	return data.Results, nil
+}
+
+func (result openCageResult) geolocation() *domain.Geolocation {
+	components := stringComponents(result.Components)
+	precision := domain.PrecisionLocality
+	switch components["_type"] {
+	case "building", "house":
+		precision = domain.PrecisionRooftop
+	case "road":
+		precision = domain.PrecisionStreet
+	}
+	return &domain.Geolocation{
+		FormattedAddress: result.Formatted,
+		Latitude:         result.Geometry.Lat,
+		Longitude:        result.Geometry.Lng,
+		Geocoder:         "opencage",
+		// OpenCage informa confianza de 0 a 10 según el tamaño del área del resultado
+		Confidence:        float64(result.Confidence) / 10,
+		Precision:         precision,
+		Components:        components,
+		ResponseCoordsApi: []interface{}{result},
+	}
+}
+
+// stringComponents conserva los componentes de texto de la dirección, como road, house_number o city
+func stringComponents(components map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(components))
+	for key, value := range components {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,68 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestOpenCageGeocode(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		file    string
+		wantErr ErrorKind
+	}{
+		{name: "resultado exacto", status: http.StatusOK, file: "opencage_hit.json"},
+		{name: "sin resultados", status: http.StatusOK, file: "opencage_empty.json", wantErr: KindNotFound},
+		{name: "api key inválida", status: http.StatusUnauthorized, file: "provider_error.json", wantErr: KindAuthQuota},
+		{name: "cuota agotada", status: http.StatusPaymentRequired, file: "provider_error.json", wantErr: KindAuthQuota},
+		{name: "límite de consultas", status: http.StatusTooManyRequests, file: "provider_error.json", wantErr: KindTransient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, tt.status, tt.file)
+			geocoder := NewOpenCageGeocoder(Options{BaseURL: server.URL, APIKey: "clave-opencage"})
+
+			geo, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234, Providencia")
+			if kind := Classify(err); kind != tt.wantErr {
+				t.Fatalf("error %v clasificado como %q, se esperaba %q", err, kind, tt.wantErr)
+			}
+			if key := server.request.URL.Query().Get("key"); key != "clave-opencage" {
+				t.Errorf("key = %q", key)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if geo.FormattedAddress != "Avenida Providencia 1234, 7500000 Providencia, Chile" {
+				t.Errorf("FormattedAddress = %q", geo.FormattedAddress)
+			}
+			if geo.Latitude != -33.4263 || geo.Longitude != -70.6109 {
+				t.Errorf("coordenadas = %v, %v", geo.Latitude, geo.Longitude)
+			}
+			if geo.Precision != domain.PrecisionRooftop || geo.Confidence != 1 {
+				t.Errorf("precisión %q, confianza %v", geo.Precision, geo.Confidence)
+			}
+			if geo.Components["house_number"] != "1234" || geo.Components["road"] != "Avenida Providencia" {
+				t.Errorf("componentes = %v", geo.Components)
+			}
+		})
+	}
+}
+
+func TestOpenCageCandidates(t *testing.T) {
+	server := newStandIn(t, http.StatusOK, "opencage_hit.json")
+	geocoder := NewOpenCageGeocoder(Options{BaseURL: server.URL, APIKey: "clave-opencage"})
+
+	candidates, err := geocoder.Candidates(context.Background(), "Avenida Providencia 1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || candidates[0].Precision != domain.PrecisionRooftop || candidates[1].Precision != domain.PrecisionStreet {
+		t.Errorf("candidatos = %+v", candidates)
+	}
+	if limit := server.request.URL.Query().Get("limit"); limit != "5" {
+		t.Errorf("limit = %q", limit)
+	}
+}
@@ -0,0 +1,99 @@
+package geocoders
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"wemaps/internal/domain"
+)
+
+// PeliasGeocoder consulta una instancia propia de Pelias; BaseURL es el endpoint de búsqueda, por ejemplo
+// "http://pelias.local:4000/v1/search". No tiene endpoint por defecto para no enviar direcciones a un servicio público.
+type PeliasGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+// NewPeliasGeocoder crea el geocodificador; por defecto acepta resultados de las capas address y venue
+func NewPeliasGeocoder(options Options) *PeliasGeocoder {
+	options = options.withDefaults("", "address", "venue")
+	return &PeliasGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (p *PeliasGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	features, err := p.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	geo, ok := peliasGeolocation(features[0])
+	if !ok {
+		return nil, ErrNotFound
+	}
+	// Un resultado de tipo fallback es un área más amplia que la dirección pedida
+	if !p.accept[geo.Components["layer"]] || geo.Components["match_type"] == "fallback" {
+		return nil, fmt.Errorf("%w: capa %q (%s)", ErrNotExact, geo.Components["layer"], geo.Components["match_type"])
+	}
+	return geo, nil
+}
+
+// Candidates retorna los resultados de Pelias ordenados, incluidos los de calle o localidad
+func (p *PeliasGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	features, err := p.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.Geolocation
+	for _, feature := range features {
+		if geo, ok := peliasGeolocation(feature); ok {
+			candidates = append(candidates, *geo)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	return rankCandidates(candidates), nil
+}
+
+func (p *PeliasGeocoder) search(ctx context.Context, address string, limit int) ([]geoJSONFeature, error) {
+	params := url.Values{}
+	params.Add("text", address)
+	params.Add("size", strconv.Itoa(limit))
+	if p.options.APIKey != "" {
+		params.Add("api_key", p.options.APIKey)
+	}
+	return searchGeoJSON(ctx, p.options, "Pelias", params)
+}
+
+// peliasGeolocation convierte el feature con la precisión según la capa y el tipo de coincidencia
+func peliasGeolocation(feature geoJSONFeature) (*domain.Geolocation, bool) {
+	lat, lon, ok := feature.point()
+	if !ok {
+		return nil, false
+	}
+	components := stringComponents(feature.Properties)
+	confidence, _ := strconv.ParseFloat(components["confidence"], 64)
+
+	precision := domain.PrecisionLocality
+	switch {
+	case components["match_type"] == "fallback":
+	case components["layer"] == "street":
+		precision = domain.PrecisionStreet
+	case components["match_type"] == "interpolated":
+		precision = domain.PrecisionInterpolated
+	case components["layer"] == "address" || components["layer"] == "venue":
+		precision = domain.PrecisionRooftop
+	}
+	return &domain.Geolocation{
+		FormattedAddress:  components["label"],
+		Latitude:          lat,
+		Longitude:         lon,
+		Geocoder:          "pelias",
+		Confidence:        confidence,
+		Precision:         precision,
+		Components:        components,
+		ResponseCoordsApi: []interface{}{feature},
+	}, true
+}
@@ -0,0 +1,90 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestPeliasGeocode(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		file    string
+		wantErr ErrorKind
+	}{
+		{name: "resultado exacto", status: http.StatusOK, file: "pelias_hit.json"},
+		{name: "resultado de localidad", status: http.StatusOK, file: "pelias_fallback.json", wantErr: KindNotExact},
+		{name: "sin resultados", status: http.StatusOK, file: "geojson_empty.json", wantErr: KindNotFound},
+		{name: "límite de consultas", status: http.StatusTooManyRequests, wantErr: KindTransient},
+		{name: "api key inválida", status: http.StatusUnauthorized, wantErr: KindAuthQuota},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, tt.status, tt.file)
+			geocoder := NewPeliasGeocoder(Options{BaseURL: server.URL + "/v1/search"})
+
+			geo, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234, Providencia")
+			if kind := Classify(err); kind != tt.wantErr {
+				t.Fatalf("error %v clasificado como %q, se esperaba %q", err, kind, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if geo.FormattedAddress != "Avenida Providencia 1234, Providencia, Chile" {
+				t.Errorf("FormattedAddress = %q", geo.FormattedAddress)
+			}
+			if geo.Latitude != -33.4263 || geo.Longitude != -70.6109 {
+				t.Errorf("coordenadas = %v, %v", geo.Latitude, geo.Longitude)
+			}
+			if geo.Precision != domain.PrecisionRooftop || geo.Confidence != 0.95 || geo.Geocoder != "pelias" {
+				t.Errorf("precisión %q, confianza %v, geocodificador %q", geo.Precision, geo.Confidence, geo.Geocoder)
+			}
+		})
+	}
+}
+
+func TestPeliasRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+	}{
+		{name: "instancia propia sin key"},
+		{name: "servicio con key", apiKey: "secreta"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, http.StatusOK, "pelias_hit.json")
+			geocoder := NewPeliasGeocoder(Options{BaseURL: server.URL + "/v1/search", APIKey: tt.apiKey})
+
+			if _, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234"); err != nil {
+				t.Fatal(err)
+			}
+			if server.request.URL.Path != "/v1/search" {
+				t.Errorf("ruta = %q, se esperaba la de base_url", server.request.URL.Path)
+			}
+			query := server.request.URL.Query()
+			if query.Get("text") != "Avenida Providencia 1234" || query.Get("size") != "1" {
+				t.Errorf("parámetros = %v", query)
+			}
+			if query.Get("api_key") != tt.apiKey {
+				t.Errorf("api_key = %q, se esperaba %q", query.Get("api_key"), tt.apiKey)
+			}
+		})
+	}
+}
+
+func TestPeliasCandidates(t *testing.T) {
+	server := newStandIn(t, http.StatusOK, "pelias_fallback.json")
+	geocoder := NewPeliasGeocoder(Options{BaseURL: server.URL + "/v1/search"})
+
+	// Los candidatos incluyen los resultados de localidad que Geocode rechaza
+	candidates, err := geocoder.Candidates(context.Background(), "Providencia")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].Precision != domain.PrecisionLocality {
+		t.Errorf("candidatos = %+v", candidates)
+	}
+}
@@ -0,0 +1,105 @@
+package geocoders
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"wemaps/internal/domain"
+)
+
+// PhotonGeocoder consulta una instancia propia de Photon; BaseURL es el endpoint de búsqueda, por ejemplo
+// "http://photon.local:2322/api". No tiene endpoint por defecto para no enviar direcciones a un servicio público.
+type PhotonGeocoder struct {
+	options Options
+	accept  map[string]bool
+}
+
+// NewPhotonGeocoder crea el geocodificador; por defecto acepta resultados de tipo house
+func NewPhotonGeocoder(options Options) *PhotonGeocoder {
+	options = options.withDefaults("", "house")
+	return &PhotonGeocoder{options: options, accept: acceptSet(options.Accept)}
+}
+
+func (p *PhotonGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	features, err := p.search(ctx, address, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	geo, ok := photonGeolocation(features[0])
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !p.accept[geo.Components["type"]] {
+		return nil, fmt.Errorf("%w: tipo de resultado %q", ErrNotExact, geo.Components["type"])
+	}
+	return geo, nil
+}
+
+// Candidates retorna los resultados de Photon ordenados, incluidos los de calle o localidad
+func (p *PhotonGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	features, err := p.search(ctx, address, MaxCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []domain.Geolocation
+	for _, feature := range features {
+		if geo, ok := photonGeolocation(feature); ok {
+			candidates = append(candidates, *geo)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNotFound
+	}
+	return rankCandidates(candidates), nil
+}
+
+func (p *PhotonGeocoder) search(ctx context.Context, address string, limit int) ([]geoJSONFeature, error) {
+	params := url.Values{}
+	params.Add("q", address)
+	params.Add("limit", strconv.Itoa(limit))
+	return searchGeoJSON(ctx, p.options, "Photon", params)
+}
+
+// photonGeolocation convierte el feature; Photon no informa confianza
+func photonGeolocation(feature geoJSONFeature) (*domain.Geolocation, bool) {
+	lat, lon, ok := feature.point()
+	if !ok {
+		return nil, false
+	}
+	components := stringComponents(feature.Properties)
+	precision := domain.PrecisionLocality
+	switch components["type"] {
+	case "house":
+		precision = domain.PrecisionRooftop
+	case "street":
+		precision = domain.PrecisionStreet
+	}
+	return &domain.Geolocation{
+		FormattedAddress:  photonLabel(components),
+		Latitude:          lat,
+		Longitude:         lon,
+		Geocoder:          "photon",
+		Precision:         precision,
+		Components:        components,
+		ResponseCoordsApi: []interface{}{feature},
+	}, true
+}
+
+// photonLabel arma la dirección formateada, Photon solo entrega los componentes
+func photonLabel(components map[string]string) string {
+	street := strings.TrimSpace(components["street"] + " " + components["housenumber"])
+	if street == "" {
+		street = components["name"]
+	}
+	var parts []string
+	for _, part := range []string{street, components["city"], components["state"], components["country"]} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,63 @@
+package geocoders
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestPhotonGeocode(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		file      string
+		wantErr   ErrorKind
+		wantLabel string
+	}{
+		{name: "resultado exacto", status: http.StatusOK, file: "photon_hit.json", wantLabel: "Avenida Providencia 1234, Providencia, Región Metropolitana de Santiago, Chile"},
+		{name: "resultado de calle", status: http.StatusOK, file: "photon_street.json", wantErr: KindNotExact},
+		{name: "sin resultados", status: http.StatusOK, file: "geojson_empty.json", wantErr: KindNotFound},
+		{name: "error del servidor", status: http.StatusBadGateway, wantErr: KindTransient},
+		{name: "solicitud inválida", status: http.StatusBadRequest, wantErr: KindUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStandIn(t, tt.status, tt.file)
+			geocoder := NewPhotonGeocoder(Options{BaseURL: server.URL + "/api"})
+
+			geo, err := geocoder.Geocode(context.Background(), "Avenida Providencia 1234, Providencia")
+			if kind := Classify(err); kind != tt.wantErr {
+				t.Fatalf("error %v clasificado como %q, se esperaba %q", err, kind, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if geo.FormattedAddress != tt.wantLabel {
+				t.Errorf("FormattedAddress = %q, se esperaba %q", geo.FormattedAddress, tt.wantLabel)
+			}
+			if geo.Latitude != -33.4263 || geo.Longitude != -70.6109 {
+				t.Errorf("coordenadas = %v, %v", geo.Latitude, geo.Longitude)
+			}
+			if geo.Precision != domain.PrecisionRooftop || geo.Geocoder != "photon" {
+				t.Errorf("precisión %q y geocodificador %q", geo.Precision, geo.Geocoder)
+			}
+		})
+	}
+}
+
+func TestPhotonRequest(t *testing.T) {
+	server := newStandIn(t, http.StatusOK, "photon_hit.json")
+	geocoder := NewPhotonGeocoder(Options{BaseURL: server.URL + "/api"})
+
+	if _, err := geocoder.Candidates(context.Background(), "Avenida Providencia 1234"); err != nil {
+		t.Fatal(err)
+	}
+	if server.request.URL.Path != "/api" {
+		t.Errorf("ruta = %q, se esperaba la de base_url", server.request.URL.Path)
+	}
+	query := server.request.URL.Query()
+	if query.Get("q") != "Avenida Providencia 1234" || query.Get("limit") != "5" {
+		t.Errorf("parámetros = %v", query)
+	}
+}
@@ -0,0 +1,114 @@
+package geocoders
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"wemaps/internal/domain"
+)
+
+// RateLimitedGeocoder limita las consultas a un proveedor con un token bucket compartido
+// por todas las goroutines que usan la misma instancia.
+type RateLimitedGeocoder struct {
+	geocoder Geocoder
+	limiter  *tokenBucket
+}
+
+// RateLimitedBatchGeocoder conserva la consulta por lote del proveedor; cada lote consume un solo token
+type RateLimitedBatchGeocoder struct {
+	*RateLimitedGeocoder
+	batch BatchGeocoder
+}
+
+// NewRateLimitedGeocoder permite rps consultas por segundo con ráfagas de hasta burst.
+// rps <= 0 deja al proveedor sin límite. Si el proveedor es un BatchGeocoder el resultado también lo es.
+func NewRateLimitedGeocoder(geocoder Geocoder, rps float64, burst int) Geocoder {
+	if rps <= 0 {
+		return geocoder
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limited := &RateLimitedGeocoder{
+		geocoder: geocoder,
+		limiter:  &tokenBucket{rate: rps, burst: float64(burst), tokens: float64(burst), last: time.Now()},
+	}
+	if batch, ok := geocoder.(BatchGeocoder); ok {
+		return &RateLimitedBatchGeocoder{RateLimitedGeocoder: limited, batch: batch}
+	}
+	return limited
+}
+
+// Geocode espera su turno en vez de fallar; solo retorna error si ctx se cancela antes
+func (r *RateLimitedGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.geocoder.Geocode(ctx, address)
+}
+
+// ReverseGeocode comparte el límite con Geocode; retorna error si el proveedor no es un ReverseGeocoder
+func (r *RateLimitedGeocoder) ReverseGeocode(ctx context.Context, lat, lon float64) (*domain.Geolocation, error) {
+	reverse, ok := r.geocoder.(ReverseGeocoder)
+	if !ok {
+		return nil, errors.New("el proveedor no admite geocodificación inversa")
+	}
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return reverse.ReverseGeocode(ctx, lat, lon)
+}
+
+// Candidates comparte el límite con Geocode; retorna error si el proveedor no es un CandidateGeocoder
+func (r *RateLimitedGeocoder) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	candidates, ok := r.geocoder.(CandidateGeocoder)
+	if !ok {
+		return nil, errors.New("el proveedor no entrega candidatos")
+	}
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return candidates.Candidates(ctx, address)
+}
+
+func (r *RateLimitedBatchGeocoder) GeocodeBatch(ctx context.Context, addresses []string) ([]BatchResult, error) {
+	if err := r.limiter.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.batch.GeocodeBatch(ctx, addresses)
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait reserva un token y duerme hasta que esté disponible; si ctx se cancela lo devuelve
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,82 @@
+package geocoders
+
+import (
+	"context"
+	"testing"
+	"time"
+	"wemaps/internal/domain"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		burst    float64
+		calls    int
+		minDelay time.Duration
+		maxDelay time.Duration
+	}{
+		// La ráfaga inicial no espera
+		{name: "dentro de la ráfaga", rate: 10, burst: 3, calls: 3, maxDelay: 50 * time.Millisecond},
+		// Tras la ráfaga cada consulta espera 1/rate: 4 consultas extra a 50 por segundo son 80 ms
+		{name: "sobre la ráfaga", rate: 50, burst: 2, calls: 6, minDelay: 70 * time.Millisecond, maxDelay: 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &tokenBucket{rate: tt.rate, burst: tt.burst, tokens: tt.burst, last: time.Now()}
+			start := time.Now()
+			for range tt.calls {
+				if err := b.wait(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if elapsed := time.Since(start); elapsed < tt.minDelay || elapsed > tt.maxDelay {
+				t.Errorf("%d consultas tardaron %v, se esperaba entre %v y %v", tt.calls, elapsed, tt.minDelay, tt.maxDelay)
+			}
+		})
+	}
+}
+
+func TestTokenBucketCancel(t *testing.T) {
+	b := &tokenBucket{rate: 1, burst: 1, tokens: 1, last: time.Now()}
+	if err := b.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Una espera cancelada devuelve su token, la siguiente no queda detrás de ella
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("error = %v, se esperaba el del contexto", err)
+	}
+	if b.tokens < -0.1 {
+		t.Errorf("tokens = %v, la espera cancelada no devolvió su token", b.tokens)
+	}
+}
+
+type batchStub struct{}
+
+func (batchStub) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	return &domain.Geolocation{FormattedAddress: address}, nil
+}
+
+func (batchStub) GeocodeBatch(ctx context.Context, addresses []string) ([]BatchResult, error) {
+	return make([]BatchResult, len(addresses)), nil
+}
+
+func TestNewRateLimitedGeocoder(t *testing.T) {
+	if geocoder := NewRateLimitedGeocoder(batchStub{}, 0, 1); geocoder != (batchStub{}) {
+		t.Errorf("sin límite se esperaba el mismo proveedor, se obtuvo %T", geocoder)
+	}
+	geocoder := NewRateLimitedGeocoder(batchStub{}, 5, 0)
+	batch, ok := geocoder.(BatchGeocoder)
+	if !ok {
+		t.Fatalf("%T no conserva la consulta por lote", geocoder)
+	}
+	if results, err := batch.GeocodeBatch(context.Background(), []string{"a", "b"}); err != nil || len(results) != 2 {
+		t.Errorf("GeocodeBatch = %v, %v", results, err)
+	}
+	if limited := geocoder.(*RateLimitedBatchGeocoder); limited.limiter.burst != 1 {
+		t.Errorf("burst = %v, se esperaba el mínimo de 1", limited.limiter.burst)
+	}
+}
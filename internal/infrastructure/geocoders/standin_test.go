@@ -0,0 +1,38 @@
+package geocoders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// standIn es un servidor local que responde como el proveedor y registra la última consulta recibida
+type standIn struct {
+	*httptest.Server
+	request *http.Request
+}
+
+// newStandIn responde status con el cuerpo del archivo de testdata indicado; sin archivo el cuerpo queda vacío
+func newStandIn(t *testing.T, status int, file string) *standIn {
+	t.Helper()
+	var body []byte
+	if file != "" {
+		var err error
+		body, err = os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("leyendo %s: %v", file, err)
+		}
+	}
+
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.request = r
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
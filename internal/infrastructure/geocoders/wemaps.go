@@ -1,8 +1,11 @@
 package geocoders
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/repository"
 	"wemaps/internal/ports"
 )
 
@@ -14,16 +17,23 @@ func NewWemapsGeocoder(repo ports.PortalRepository) *WemapsGeocoder {
 	return &WemapsGeocoder{repo: repo}
 }
 
-func (w *WemapsGeocoder) Geocode(address string) (*domain.Geolocation, error) {
+func (w *WemapsGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Consultar la dirección en la base de datos local
 	geo, err := w.repo.FindAddress(address)
+	if errors.Is(err, repository.ErrAddressNotFound) {
+		return nil, fmt.Errorf("%w en Wemaps: %v", ErrNotFound, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error al consultar la dirección en Wemaps: %v", err)
 	}
 
 	// Verificar si se obtuvo un resultado válido
 	if geo.FormattedAddress == "" {
-		return nil, fmt.Errorf("no se encontraron resultados para la dirección: %s", address)
+		return nil, fmt.Errorf("%w para la dirección: %s", ErrNotFound, address)
 	}
 
 	// Mapear el resultado a domain.Geolocation
@@ -32,6 +42,8 @@ func (w *WemapsGeocoder) Geocode(address string) (*domain.Geolocation, error) {
 		Latitude:         geo.Latitude,
 		Longitude:        geo.Longitude,
 		Geocoder:         "wemaps",
+		// Las direcciones de Wemaps son direcciones ya validadas
+		Precision: domain.PrecisionRooftop,
 		ResponseCoordsApi: []interface{}{map[string]interface{}{
 			"formatted_address": geo.FormattedAddress,
 			"latitude":          geo.Latitude,
@@ -0,0 +1,58 @@
+package gis
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+type geoJSONWriter struct {
+	w       *bufio.Writer
+	columns []string
+	count   int
+}
+
+func newGeoJSONWriter(w io.Writer, columns []string) (*geoJSONWriter, error) {
+	writer := &geoJSONWriter{w: bufio.NewWriter(w), columns: columns}
+	_, err := writer.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	return writer, err
+}
+
+// WriteFeature escribe las propiedades en el orden de las columnas del reporte
+func (g *geoJSONWriter) WriteFeature(feature Feature) error {
+	if g.count > 0 {
+		g.w.WriteByte(',')
+	}
+	g.count++
+
+	g.w.WriteString(`{"type":"Feature","geometry":`)
+	if feature.HasGeometry() {
+		coordinates, _ := json.Marshal([]float64{feature.Longitude, feature.Latitude})
+		g.w.WriteString(`{"type":"Point","coordinates":`)
+		g.w.Write(coordinates)
+		g.w.WriteByte('}')
+	} else {
+		g.w.WriteString("null")
+	}
+
+	g.w.WriteString(`,"properties":{`)
+	for i, column := range g.columns {
+		if i > 0 {
+			g.w.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, _ := json.Marshal(feature.Values[i])
+		g.w.Write(key)
+		g.w.WriteByte(':')
+		g.w.Write(value)
+	}
+	_, err := g.w.WriteString("}}")
+	return err
+}
+
+func (g *geoJSONWriter) Close() error {
+	if _, err := g.w.WriteString("]}"); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
@@ -0,0 +1,65 @@
+package gis
+
+import (
+	"fmt"
+	"io"
+)
+
+// Formatos de exportación soportados
+const (
+	FormatGeoJSON   = "geojson"
+	FormatKML       = "kml"
+	FormatGPX       = "gpx"
+	FormatShapefile = "shp"
+)
+
+// Feature es una fila del reporte con sus valores en el orden de las columnas del writer.
+// Una fila sin coordenadas (0, 0) se exporta sin geometría cuando el formato lo permite.
+type Feature struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Values    []string
+}
+
+func (f Feature) HasGeometry() bool {
+	return f.Latitude != 0 || f.Longitude != 0
+}
+
+// Writer escribe features a medida que llegan; Close completa el archivo
+type Writer interface {
+	WriteFeature(feature Feature) error
+	Close() error
+}
+
+// NewWriter crea el writer del formato indicado. name es el nombre de la capa o del archivo.
+func NewWriter(format string, w io.Writer, name string, columns []string) (Writer, error) {
+	switch format {
+	case FormatGeoJSON:
+		return newGeoJSONWriter(w, columns)
+	case FormatKML:
+		return newKMLWriter(w, name, columns)
+	case FormatGPX:
+		return newGPXWriter(w, name, columns)
+	case FormatShapefile:
+		return newShapefileWriter(w, name, columns)
+	default:
+		return nil, fmt.Errorf("formato de exportación no soportado: %s", format)
+	}
+}
+
+// ContentType retorna el tipo MIME y la extensión del archivo exportado
+func ContentType(format string) (string, string) {
+	switch format {
+	case FormatGeoJSON:
+		return "application/geo+json", ".geojson"
+	case FormatKML:
+		return "application/vnd.google-earth.kml+xml", ".kml"
+	case FormatGPX:
+		return "application/gpx+xml", ".gpx"
+	case FormatShapefile:
+		return "application/zip", ".zip"
+	default:
+		return "application/octet-stream", ""
+	}
+}
@@ -0,0 +1,138 @@
+package gis
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+var (
+	testColumns  = []string{"Dirección", "Comuna", "Nota"}
+	testFeatures = []Feature{
+		{Name: "Av. Providencia 1234", Latitude: -33.4263, Longitude: -70.6109, Values: []string{"Av. Providencia 1234", "Providencia", `local "B" & <2>`}},
+		{Name: "Sin coordenadas", Values: []string{"Pasaje sin nombre", "Maipú", ""}},
+	}
+)
+
+// export escribe testFeatures en el formato indicado y retorna el archivo completo
+func export(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf, "Reporte clientes", testColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, feature := range testFeatures {
+		if err := writer.WriteFeature(feature); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGeoJSONWriter(t *testing.T) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Type     string `json:"type"`
+			Geometry *struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]string `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(export(t, FormatGeoJSON), &collection); err != nil {
+		t.Fatalf("GeoJSON inválido: %v", err)
+	}
+
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Fatalf("colección %s con %d features", collection.Type, len(collection.Features))
+	}
+	point := collection.Features[0]
+	if point.Geometry == nil || point.Geometry.Type != "Point" || !reflect.DeepEqual(point.Geometry.Coordinates, []float64{-70.6109, -33.4263}) {
+		t.Errorf("geometría = %+v, se esperaba Point en lon, lat", point.Geometry)
+	}
+	if want := map[string]string{"Dirección": "Av. Providencia 1234", "Comuna": "Providencia", "Nota": `local "B" & <2>`}; !reflect.DeepEqual(point.Properties, want) {
+		t.Errorf("propiedades = %v, se esperaban %v", point.Properties, want)
+	}
+	if collection.Features[1].Geometry != nil {
+		t.Errorf("la fila sin coordenadas tiene geometría %+v", collection.Features[1].Geometry)
+	}
+}
+
+func TestKMLWriter(t *testing.T) {
+	var kml struct {
+		Document struct {
+			Name       string `xml:"name"`
+			Placemarks []struct {
+				Name string `xml:"name"`
+				Data []struct {
+					Name  string `xml:"name,attr"`
+					Value string `xml:"value"`
+				} `xml:"ExtendedData>Data"`
+				Coordinates string `xml:"Point>coordinates"`
+			} `xml:"Placemark"`
+		} `xml:"Document"`
+	}
+	if err := xml.Unmarshal(export(t, FormatKML), &kml); err != nil {
+		t.Fatalf("KML inválido: %v", err)
+	}
+
+	if kml.Document.Name != "Reporte clientes" || len(kml.Document.Placemarks) != 2 {
+		t.Fatalf("documento %q con %d placemarks", kml.Document.Name, len(kml.Document.Placemarks))
+	}
+	placemark := kml.Document.Placemarks[0]
+	if placemark.Name != "Av. Providencia 1234" || placemark.Coordinates != "-70.6109,-33.4263" {
+		t.Errorf("placemark %q en %q", placemark.Name, placemark.Coordinates)
+	}
+	if len(placemark.Data) != len(testColumns) || placemark.Data[2].Name != "Nota" || placemark.Data[2].Value != `local "B" & <2>` {
+		t.Errorf("ExtendedData = %+v", placemark.Data)
+	}
+	if kml.Document.Placemarks[1].Coordinates != "" {
+		t.Errorf("la fila sin coordenadas tiene punto %q", kml.Document.Placemarks[1].Coordinates)
+	}
+}
+
+func TestGPXWriter(t *testing.T) {
+	var gpx struct {
+		Version   string `xml:"version,attr"`
+		Name      string `xml:"metadata>name"`
+		Waypoints []struct {
+			Lat     float64 `xml:"lat,attr"`
+			Lon     float64 `xml:"lon,attr"`
+			Name    string  `xml:"name"`
+			Columns []struct {
+				XMLName xml.Name
+				Name    string `xml:"name,attr"`
+				Value   string `xml:",chardata"`
+			} `xml:"extensions>column"`
+		} `xml:"wpt"`
+	}
+	if err := xml.Unmarshal(export(t, FormatGPX), &gpx); err != nil {
+		t.Fatalf("GPX inválido: %v", err)
+	}
+
+	// GPX exige coordenadas, la fila sin geometría se omite
+	if gpx.Version != "1.1" || gpx.Name != "Reporte clientes" || len(gpx.Waypoints) != 1 {
+		t.Fatalf("gpx %s %q con %d waypoints", gpx.Version, gpx.Name, len(gpx.Waypoints))
+	}
+	wpt := gpx.Waypoints[0]
+	if wpt.Lat != -33.4263 || wpt.Lon != -70.6109 || wpt.Name != "Av. Providencia 1234" {
+		t.Errorf("waypoint %q en %v, %v", wpt.Name, wpt.Lat, wpt.Lon)
+	}
+	if len(wpt.Columns) != len(testColumns) || wpt.Columns[2].XMLName.Space != gpxNamespace || wpt.Columns[2].Value != `local "B" & <2>` {
+		t.Errorf("extensiones = %+v", wpt.Columns)
+	}
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("dxf", &bytes.Buffer{}, "reporte", testColumns); err == nil {
+		t.Error("se esperaba error para un formato no soportado")
+	}
+}
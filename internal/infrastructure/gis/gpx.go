@@ -0,0 +1,55 @@
+package gis
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// gpxNamespace es el namespace de las extensiones con las columnas del reporte
+const gpxNamespace = "https://wemaps.com/gpx/1"
+
+type gpxWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func newGPXWriter(w io.Writer, name string, columns []string) (*gpxWriter, error) {
+	writer := &gpxWriter{w: bufio.NewWriter(w), columns: columns}
+	writer.w.WriteString(xml.Header)
+	fmt.Fprintf(writer.w, `<gpx version="1.1" creator="WeMaps" xmlns="http://www.topografix.com/GPX/1/1" xmlns:wm="%s">`, gpxNamespace)
+	writer.w.WriteString("<metadata><name>")
+	xml.EscapeText(writer.w, []byte(name))
+	_, err := writer.w.WriteString("</name></metadata>\n")
+	return writer, err
+}
+
+// WriteFeature escribe un waypoint; GPX exige coordenadas, las filas sin geometría se omiten
+func (g *gpxWriter) WriteFeature(feature Feature) error {
+	if !feature.HasGeometry() {
+		return nil
+	}
+
+	fmt.Fprintf(g.w, `<wpt lat="%s" lon="%s"><name>`,
+		strconv.FormatFloat(feature.Latitude, 'f', -1, 64), strconv.FormatFloat(feature.Longitude, 'f', -1, 64))
+	xml.EscapeText(g.w, []byte(feature.Name))
+	g.w.WriteString("</name><extensions>")
+	for i, column := range g.columns {
+		g.w.WriteString(`<wm:column name="`)
+		xml.EscapeText(g.w, []byte(column))
+		g.w.WriteString(`">`)
+		xml.EscapeText(g.w, []byte(feature.Values[i]))
+		g.w.WriteString("</wm:column>")
+	}
+	_, err := g.w.WriteString("</extensions></wpt>\n")
+	return err
+}
+
+func (g *gpxWriter) Close() error {
+	if _, err := g.w.WriteString("</gpx>\n"); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
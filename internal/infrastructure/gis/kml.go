@@ -0,0 +1,51 @@
+package gis
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type kmlWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func newKMLWriter(w io.Writer, name string, columns []string) (*kmlWriter, error) {
+	writer := &kmlWriter{w: bufio.NewWriter(w), columns: columns}
+	writer.w.WriteString(xml.Header)
+	writer.w.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>`)
+	xml.EscapeText(writer.w, []byte(name))
+	_, err := writer.w.WriteString("</name>\n")
+	return writer, err
+}
+
+// WriteFeature escribe un Placemark con las columnas del reporte como ExtendedData
+func (k *kmlWriter) WriteFeature(feature Feature) error {
+	k.w.WriteString("<Placemark><name>")
+	xml.EscapeText(k.w, []byte(feature.Name))
+	k.w.WriteString("</name><ExtendedData>")
+	for i, column := range k.columns {
+		k.w.WriteString(`<Data name="`)
+		xml.EscapeText(k.w, []byte(column))
+		k.w.WriteString(`"><value>`)
+		xml.EscapeText(k.w, []byte(feature.Values[i]))
+		k.w.WriteString("</value></Data>")
+	}
+	k.w.WriteString("</ExtendedData>")
+	if feature.HasGeometry() {
+		fmt.Fprintf(k.w, "<Point><coordinates>%s,%s</coordinates></Point>",
+			strconv.FormatFloat(feature.Longitude, 'f', -1, 64), strconv.FormatFloat(feature.Latitude, 'f', -1, 64))
+	}
+	_, err := k.w.WriteString("</Placemark>\n")
+	return err
+}
+
+func (k *kmlWriter) Close() error {
+	if _, err := k.w.WriteString("</Document></kml>\n"); err != nil {
+		return err
+	}
+	return k.w.Flush()
+}
@@ -0,0 +1,278 @@
+package gis
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	shpHeaderSize  = 100
+	shpFileCode    = 9994
+	shpVersion     = 1000
+	shpTypeNull    = 0
+	shpTypePoint   = 1
+	dbfFieldWidth  = 254
+	dbfFieldName   = 10
+	dbfMaxFields   = 255
+	dbfHeaderSize  = 32
+	dbfDescriptor  = 32
+	dbfTerminator  = 0x0D
+	dbfEndOfFile   = 0x1A
+	shpPointWords  = 10
+	shpNullWords   = 2
+	shpRecordWords = 4
+)
+
+// wgs84 es la proyección de las coordenadas del geocodificador
+const wgs84 = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+// shapefileWriter escribe .shp, .shx y .dbf en archivos temporales porque los encabezados
+// dependen del total de registros, y al cerrar los comprime en un zip junto al .prj y .cpg.
+type shapefileWriter struct {
+	w       io.Writer
+	name    string
+	columns []string
+	fields  []string
+
+	shp, shx, dbf *os.File
+	shpBuf        *bufio.Writer
+	shxBuf        *bufio.Writer
+	dbfBuf        *bufio.Writer
+
+	records int
+	offset  int // en palabras de 16 bits
+	bbox    [4]float64
+	hasBBox bool
+}
+
+func newShapefileWriter(w io.Writer, name string, columns []string) (*shapefileWriter, error) {
+	if len(columns) > dbfMaxFields {
+		return nil, fmt.Errorf("el shapefile admite hasta %d columnas, el reporte tiene %d", dbfMaxFields, len(columns))
+	}
+
+	s := &shapefileWriter{
+		w:       w,
+		name:    shapefileName(name),
+		columns: columns,
+		fields:  dbfFieldNames(columns),
+		offset:  shpHeaderSize / 2,
+	}
+
+	var err error
+	for _, file := range []**os.File{&s.shp, &s.shx, &s.dbf} {
+		if *file, err = os.CreateTemp("", "wemaps-shp-*"); err != nil {
+			s.cleanup()
+			return nil, fmt.Errorf("error creando archivo temporal: %v", err)
+		}
+	}
+	s.shpBuf = bufio.NewWriter(s.shp)
+	s.shxBuf = bufio.NewWriter(s.shx)
+	s.dbfBuf = bufio.NewWriter(s.dbf)
+
+	// Los encabezados se reescriben al cerrar con los totales
+	s.shpBuf.Write(make([]byte, shpHeaderSize))
+	s.shxBuf.Write(make([]byte, shpHeaderSize))
+	s.dbfBuf.Write(s.dbfHeader())
+	return s, nil
+}
+
+func (s *shapefileWriter) WriteFeature(feature Feature) error {
+	s.records++
+
+	words := shpNullWords
+	if feature.HasGeometry() {
+		words = shpPointWords
+	}
+
+	// Índice: desplazamiento y largo del registro
+	binary.Write(s.shxBuf, binary.BigEndian, [2]int32{int32(s.offset), int32(words)})
+
+	// Registro: encabezado big endian y contenido little endian
+	binary.Write(s.shpBuf, binary.BigEndian, [2]int32{int32(s.records), int32(words)})
+	if feature.HasGeometry() {
+		binary.Write(s.shpBuf, binary.LittleEndian, int32(shpTypePoint))
+		binary.Write(s.shpBuf, binary.LittleEndian, [2]float64{feature.Longitude, feature.Latitude})
+		s.extend(feature.Longitude, feature.Latitude)
+	} else {
+		binary.Write(s.shpBuf, binary.LittleEndian, int32(shpTypeNull))
+	}
+	s.offset += shpRecordWords + words
+
+	// Atributos: marca de borrado y cada valor con ancho fijo
+	s.dbfBuf.WriteByte(' ')
+	for _, value := range feature.Values {
+		value = truncateUTF8(value, dbfFieldWidth)
+		s.dbfBuf.WriteString(value)
+		s.dbfBuf.WriteString(strings.Repeat(" ", dbfFieldWidth-len(value)))
+	}
+	return nil
+}
+
+func (s *shapefileWriter) Close() error {
+	defer s.cleanup()
+
+	s.dbfBuf.WriteByte(dbfEndOfFile)
+	for _, buf := range []*bufio.Writer{s.shpBuf, s.shxBuf, s.dbfBuf} {
+		if err := buf.Flush(); err != nil {
+			return fmt.Errorf("error escribiendo shapefile: %v", err)
+		}
+	}
+
+	if _, err := s.shp.WriteAt(s.shpHeader(s.offset), 0); err != nil {
+		return fmt.Errorf("error escribiendo shapefile: %v", err)
+	}
+	if _, err := s.shx.WriteAt(s.shpHeader(shpHeaderSize/2+s.records*shpRecordWords), 0); err != nil {
+		return fmt.Errorf("error escribiendo shapefile: %v", err)
+	}
+	if _, err := s.dbf.WriteAt(s.dbfHeader(), 0); err != nil {
+		return fmt.Errorf("error escribiendo shapefile: %v", err)
+	}
+
+	archive := zip.NewWriter(s.w)
+	for _, entry := range []struct {
+		ext  string
+		file *os.File
+	}{{".shp", s.shp}, {".shx", s.shx}, {".dbf", s.dbf}} {
+		if _, err := entry.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		part, err := archive.Create(s.name + entry.ext)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, entry.file); err != nil {
+			return err
+		}
+	}
+	for ext, content := range map[string]string{".prj": wgs84, ".cpg": "UTF-8"} {
+		part, err := archive.Create(s.name + ext)
+		if err != nil {
+			return err
+		}
+		io.WriteString(part, content)
+	}
+	return archive.Close()
+}
+
+func (s *shapefileWriter) cleanup() {
+	for _, file := range []*os.File{s.shp, s.shx, s.dbf} {
+		if file != nil {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}
+}
+
+func (s *shapefileWriter) extend(x, y float64) {
+	if !s.hasBBox {
+		s.bbox = [4]float64{x, y, x, y}
+		s.hasBBox = true
+		return
+	}
+	s.bbox[0] = math.Min(s.bbox[0], x)
+	s.bbox[1] = math.Min(s.bbox[1], y)
+	s.bbox[2] = math.Max(s.bbox[2], x)
+	s.bbox[3] = math.Max(s.bbox[3], y)
+}
+
+// shpHeader arma el encabezado común de .shp y .shx, length va en palabras de 16 bits
+func (s *shapefileWriter) shpHeader(length int) []byte {
+	header := make([]byte, shpHeaderSize)
+	binary.BigEndian.PutUint32(header[0:], shpFileCode)
+	binary.BigEndian.PutUint32(header[24:], uint32(length))
+	binary.LittleEndian.PutUint32(header[28:], shpVersion)
+	binary.LittleEndian.PutUint32(header[32:], shpTypePoint)
+	for i, v := range s.bbox {
+		binary.LittleEndian.PutUint64(header[36+i*8:], math.Float64bits(v))
+	}
+	return header
+}
+
+// dbfHeader arma el encabezado dBase III con un campo de texto por columna
+func (s *shapefileWriter) dbfHeader() []byte {
+	headerLength := dbfHeaderSize + dbfDescriptor*len(s.fields) + 1
+	header := make([]byte, headerLength)
+
+	now := time.Now()
+	header[0] = 0x03
+	header[1], header[2], header[3] = byte(now.Year()-1900), byte(now.Month()), byte(now.Day())
+	binary.LittleEndian.PutUint32(header[4:], uint32(s.records))
+	binary.LittleEndian.PutUint16(header[8:], uint16(headerLength))
+	binary.LittleEndian.PutUint16(header[10:], uint16(1+dbfFieldWidth*len(s.fields)))
+
+	for i, field := range s.fields {
+		descriptor := header[dbfHeaderSize+i*dbfDescriptor:]
+		copy(descriptor[0:dbfFieldName], field)
+		descriptor[11] = 'C'
+		descriptor[16] = dbfFieldWidth
+	}
+	header[headerLength-1] = dbfTerminator
+	return header
+}
+
+// dbfFieldNames convierte las columnas en nombres dBase: ASCII, hasta 10 caracteres y sin repetir
+func dbfFieldNames(columns []string) []string {
+	fields := make([]string, len(columns))
+	used := make(map[string]bool)
+	for i, column := range columns {
+		base := asciiName(column, dbfFieldName)
+		if base == "" {
+			base = "CAMPO"
+		}
+		name := base
+		for n := 1; used[strings.ToUpper(name)]; n++ {
+			suffix := fmt.Sprintf("_%d", n)
+			name = base[:min(len(base), dbfFieldName-len(suffix))] + suffix
+		}
+		used[strings.ToUpper(name)] = true
+		fields[i] = name
+	}
+	return fields
+}
+
+// asciiName quita acentos y reemplaza los caracteres no alfanuméricos por guion bajo
+func asciiName(s string, limit int) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if sb.Len() >= limit {
+			break
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return strings.Trim(sb.String(), "_")
+}
+
+func shapefileName(name string) string {
+	if name = asciiName(name, 64); name == "" {
+		return "reporte"
+	}
+	return name
+}
+
+// truncateUTF8 corta s a lo más limit bytes sin partir un carácter
+func truncateUTF8(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && !utf8.RuneStart(s[limit]) {
+		limit--
+	}
+	return s[:limit]
+}
@@ -0,0 +1,110 @@
+package gis
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// unzipShapefile retorna el contenido de cada archivo del zip por nombre
+func unzipShapefile(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip inválido: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = content
+	}
+	return files
+}
+
+func TestShapefileWriter(t *testing.T) {
+	files := unzipShapefile(t, export(t, FormatShapefile))
+	for _, ext := range []string{".shp", ".shx", ".dbf", ".prj", ".cpg"} {
+		if _, ok := files["Reporte_clientes"+ext]; !ok {
+			t.Fatalf("falta Reporte_clientes%s en el zip", ext)
+		}
+	}
+	shp, shx, dbf := files["Reporte_clientes.shp"], files["Reporte_clientes.shx"], files["Reporte_clientes.dbf"]
+
+	// Encabezados .shp y .shx: el largo va en palabras de 16 bits
+	for name, file := range map[string][]byte{"shp": shp, "shx": shx} {
+		if code := binary.BigEndian.Uint32(file[0:]); code != shpFileCode {
+			t.Errorf("%s: código %d", name, code)
+		}
+		if length := int(binary.BigEndian.Uint32(file[24:])) * 2; length != len(file) {
+			t.Errorf("%s: largo del encabezado %d, el archivo tiene %d bytes", name, length, len(file))
+		}
+		if shapeType := binary.LittleEndian.Uint32(file[32:]); shapeType != shpTypePoint {
+			t.Errorf("%s: tipo %d", name, shapeType)
+		}
+	}
+	// Un punto y un registro nulo, cada uno con su encabezado de 8 bytes
+	if want := shpHeaderSize + (8 + 20) + (8 + 4); len(shp) != want {
+		t.Errorf("shp de %d bytes, se esperaban %d", len(shp), want)
+	}
+	if want := shpHeaderSize + 2*8; len(shx) != want {
+		t.Errorf("shx de %d bytes, se esperaban %d", len(shx), want)
+	}
+	var bbox [4]float64
+	for i := range bbox {
+		bbox[i] = math.Float64frombits(binary.LittleEndian.Uint64(shp[36+i*8:]))
+	}
+	if want := [4]float64{-70.6109, -33.4263, -70.6109, -33.4263}; bbox != want {
+		t.Errorf("bbox = %v, se esperaba %v", bbox, want)
+	}
+
+	// Encabezado dBase: registros, largo del encabezado y de cada registro
+	records := int(binary.LittleEndian.Uint32(dbf[4:]))
+	headerLength := int(binary.LittleEndian.Uint16(dbf[8:]))
+	recordLength := int(binary.LittleEndian.Uint16(dbf[10:]))
+	if records != len(testFeatures) {
+		t.Errorf("dbf con %d registros, se esperaban %d", records, len(testFeatures))
+	}
+	if want := dbfHeaderSize + dbfDescriptor*len(testColumns) + 1; headerLength != want || dbf[headerLength-1] != dbfTerminator {
+		t.Errorf("encabezado dbf de %d bytes, se esperaban %d", headerLength, want)
+	}
+	if want := 1 + dbfFieldWidth*len(testColumns); recordLength != want {
+		t.Errorf("registro dbf de %d bytes, se esperaban %d", recordLength, want)
+	}
+	if want := headerLength + records*recordLength + 1; len(dbf) != want || dbf[len(dbf)-1] != dbfEndOfFile {
+		t.Errorf("dbf de %d bytes, se esperaban %d", len(dbf), want)
+	}
+
+	var fields []string
+	for i := range testColumns {
+		descriptor := dbf[dbfHeaderSize+i*dbfDescriptor:]
+		fields = append(fields, strings.TrimRight(string(descriptor[:dbfFieldName+1]), "\x00"))
+	}
+	if want := []string{"Direccion", "Comuna", "Nota"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("campos = %q, se esperaban %q", fields, want)
+	}
+	value := string(dbf[headerLength+1+dbfFieldWidth : headerLength+1+2*dbfFieldWidth])
+	if strings.TrimRight(value, " ") != "Providencia" {
+		t.Errorf("valor de Comuna = %q", value)
+	}
+}
+
+func TestDBFFieldNames(t *testing.T) {
+	got := dbfFieldNames([]string{"Dirección Normalizada", "Dirección Normal", "Número", "", "%%"})
+	want := []string{"Direccion", "Direccio_1", "Numero", "CAMPO", "CAMPO_1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dbfFieldNames() = %q, se esperaba %q", got, want)
+	}
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"wemaps/internal/domain"
+)
+
+// ensureJobTable crea la tabla de tareas de carga si no existe.
+// El indice parcial, creado en migrateJobIndex, garantiza que una misma definicion no tenga dos tareas vigentes por usuario.
+func (db *PortalRepository) ensureJobTable() error {
+	query := `
+		CREATE TABLE IF NOT EXISTS job (
+			id SERIAL PRIMARY KEY,
+			id_task VARCHAR(64) NOT NULL UNIQUE,
+			definition_hash VARCHAR(64) NOT NULL,
+			definition TEXT NOT NULL DEFAULT '',
+			session_id VARCHAR(36) NOT NULL DEFAULT '',
+			author INT NOT NULL,
+			report_id INT,
+			report_name TEXT NOT NULL DEFAULT '',
+			status VARCHAR(20) NOT NULL,
+			record_process INT NOT NULL DEFAULT 0,
+			total_records INT NOT NULL DEFAULT 0,
+			id_error VARCHAR(10) NOT NULL DEFAULT '',
+			message TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := db.Exec(query); err != nil {
+		return fmt.Errorf("error creating job table: %v", err)
+	}
+	if err := db.migrateJobColumns(); err != nil {
+		return err
+	}
+	return db.migrateJobIndex()
+}
+
+// migrateJobColumns agrega a una tabla job existente las columnas que permiten reanudar la tarea;
+// CREATE TABLE IF NOT EXISTS no modifica una tabla ya creada
+func (db *PortalRepository) migrateJobColumns() error {
+	queries := []string{
+		`ALTER TABLE job ADD COLUMN IF NOT EXISTS definition TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE job ADD COLUMN IF NOT EXISTS session_id VARCHAR(36) NOT NULL DEFAULT ''`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf("error migrating job columns: %v", err)
+		}
+	}
+	return nil
+}
+
+// activeJob es el predicado del indice parcial: solo las tareas vigentes bloquean la definicion,
+// una tarea terminada, con error o cancelada puede volver a gatillarse
+const activeJob = `status IN ('initial', 'in process', 'paused')`
+
+// migrateJobIndex reemplaza el indice job_definition_unique, que tambien incluia las tareas terminadas,
+// por job_definition_active; CREATE UNIQUE INDEX IF NOT EXISTS no cambia el predicado de un indice existente
+func (db *PortalRepository) migrateJobIndex() error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error migrating job index: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		DROP INDEX IF EXISTS job_definition_unique;
+		CREATE UNIQUE INDEX IF NOT EXISTS job_definition_active
+			ON job (author, definition_hash) WHERE ` + activeJob + `;
+	`
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("error migrating job index: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error migrating job index: %v", err)
+	}
+	return nil
+}
+
+const jobColumns = `id, id_task, definition_hash, definition, session_id, author, COALESCE(report_id, -1), report_name, status,
+	record_process, total_records, id_error, message, created_at, updated_at`
+
+func scanJob(row interface{ Scan(...any) error }) (domain.Job, error) {
+	var job domain.Job
+	err := row.Scan(
+		&job.ID,
+		&job.IdTask,
+		&job.DefinitionHash,
+		&job.Definition,
+		&job.SessionID,
+		&job.UserID,
+		&job.ReportID,
+		&job.ReportName,
+		&job.Status,
+		&job.RecordProcess,
+		&job.TotalRecords,
+		&job.IdError,
+		&job.Message,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	return job, err
+}
+
+// CreateJob inserta la tarea si no existe otra vigente con la misma definicion.
+// Retorna la tarea existente y false cuando la definicion ya fue gatillada.
+func (db *PortalRepository) CreateJob(job domain.Job) (domain.Job, bool, error) {
+	queryInsert := `
+		INSERT INTO job (id_task, definition_hash, definition, session_id, author, report_name, status, total_records)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (author, definition_hash) WHERE ` + activeJob + ` DO NOTHING
+		RETURNING ` + jobColumns
+
+	created, err := scanJob(db.QueryRow(queryInsert,
+		job.IdTask, job.DefinitionHash, job.Definition, job.SessionID, job.UserID, job.ReportName, job.Status, job.TotalRecords))
+	if err == nil {
+		return created, true, nil
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("error creating job: %v", err)
+		return domain.Job{}, false, fmt.Errorf("error creating job: %v", err)
+	}
+
+	queryExisting := `SELECT ` + jobColumns + ` FROM job
+		WHERE author = $1 AND definition_hash = $2 AND ` + activeJob
+	existing, err := scanJob(db.QueryRow(queryExisting, job.UserID, job.DefinitionHash))
+	if err != nil {
+		log.Printf("error finding existing job: %v", err)
+		return domain.Job{}, false, fmt.Errorf("error finding existing job: %v", err)
+	}
+	return existing, false, nil
+}
+
+func (db *PortalRepository) GetJob(idTask string) (domain.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM job WHERE id_task = $1`
+	job, err := scanJob(db.QueryRow(query, idTask))
+	if err == sql.ErrNoRows {
+		return domain.Job{}, fmt.Errorf("job %s not found", idTask)
+	}
+	if err != nil {
+		return domain.Job{}, fmt.Errorf("error querying job: %v", err)
+	}
+	return job, nil
+}
+
+// GetUnfinishedJobs retorna las tareas que quedaron iniciadas, en proceso o pausadas
+func (db *PortalRepository) GetUnfinishedJobs() ([]domain.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM job WHERE status IN ($1, $2, $3) ORDER BY created_at`
+	rows, err := db.Query(query, domain.JobStatusInitial, domain.JobStatusInProcess, domain.JobStatusPaused)
+	if err != nil {
+		log.Printf("Error querying unfinished jobs: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			log.Printf("Error scanning job: %v", err)
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (db *PortalRepository) SetJobReport(idTask string, reportID int) error {
+	query := `UPDATE job SET report_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id_task = $2`
+	if _, err := db.Exec(query, reportID, idTask); err != nil {
+		return fmt.Errorf("error linking job to report: %v", err)
+	}
+	return nil
+}
+
+func (db *PortalRepository) UpdateJobStatus(idTask string, status string, recordProcess int, idError string, message string) error {
+	query := `
+		UPDATE job
+		SET status = $1, record_process = $2, id_error = $3, message = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id_task = $5
+	`
+	if _, err := db.Exec(query, status, recordProcess, idError, message, idTask); err != nil {
+		return fmt.Errorf("error updating job status: %v", err)
+	}
+	return nil
+}
+
+// UpdateJobProgress avanza el checkpoint de la tarea; una tarea pausada o cancelada mientras se guardaba
+// la fila conserva su estado
+func (db *PortalRepository) UpdateJobProgress(idTask string, recordProcess int) error {
+	query := `
+		UPDATE job
+		SET status = CASE WHEN status IN ('paused', 'cancelled') THEN status ELSE 'in process' END,
+			record_process = $1, id_error = '', message = '', updated_at = CURRENT_TIMESTAMP
+		WHERE id_task = $2
+	`
+	if _, err := db.Exec(query, recordProcess, idTask); err != nil {
+		return fmt.Errorf("error updating job progress: %v", err)
+	}
+	return nil
+}
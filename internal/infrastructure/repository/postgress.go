@@ -3,9 +3,12 @@ package repository
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"sort"
 	"sync"
 	"time"
 	"wemaps/internal/adapters/http/dto"
@@ -72,7 +75,15 @@ func makePostgresDB(host, port, user, password, dbname string) (*PortalRepositor
 	}
 
 	log.Println("Conexión a PostgreSQL establecida")
-	return &PortalRepository{db}, nil
+
+	repo := &PortalRepository{db}
+	if err = repo.ensureJobTable(); err != nil {
+		return nil, err
+	}
+	if err = repo.ensureReportColumnPosition(); err != nil {
+		return nil, err
+	}
+	return repo, nil
 }
 
 func (db *PortalRepository) Close() error {
@@ -137,7 +148,7 @@ func (db *PortalRepository) LogSession(sessionID string, userID int, tokenString
 		log.Printf("error logging session: %v", err)
 		return
 	}
-	log.Println("Session logged successfully %s", active)
+	log.Printf("Session logged successfully %v", active)
 }
 
 func (db *PortalRepository) SaveAddress(reportID int, address string, latitude float64, longitude float64, formatAddress string, geocoder string) (int, error) {
@@ -177,8 +188,10 @@ func (db *PortalRepository) SaveAddress(reportID int, address string, latitude f
 
 func (db *PortalRepository) SaveAddressInReport(reportID int, addressID int, latitude float64, longitude float64, formatAddress string, geocoder string) (int, error) {
 
+	// El vínculo es único por reporte y dirección, así un reintento desde el checkpoint no lo repite
 	linkQuery := `INSERT INTO report_address (report_id, address_id)
-				  VALUES ($1, $2)`
+				  SELECT $1, $2
+				  WHERE NOT EXISTS (SELECT 1 FROM report_address WHERE report_id = $1 AND address_id = $2)`
 	_, err := db.Exec(linkQuery, reportID, addressID)
 	if err != nil {
 		log.Printf("error linking address to report: %v", err)
@@ -188,19 +201,100 @@ func (db *PortalRepository) SaveAddressInReport(reportID int, addressID int, lat
 	return addressID, nil
 }
 
-func (db *PortalRepository) SaveReportColumnByIdReport(reportID int, addressID int, infoReport map[string]string, index int) (int, error) {
+// ensureReportColumnPosition agrega la posición original de cada columna del reporte.
+// index_column ya identifica la fila (las consultas de filas agrupan por él), por eso el orden de la
+// columna dentro del archivo necesita su propia columna column_position.
+func (db *PortalRepository) ensureReportColumnPosition() error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error adding column_position to report_column: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `ALTER TABLE report_column ADD COLUMN IF NOT EXISTS column_position INT NOT NULL DEFAULT 0`
+	if _, err := tx.Exec(query); err != nil {
+		return fmt.Errorf("error adding column_position to report_column: %v", err)
+	}
+
+	// Los reportes guardados antes quedan con todas sus columnas en 0. Se guardaban en el orden de un map,
+	// así que no hay orden original que recuperar: se dejan las columnas del archivo en orden alfabético y
+	// al final las agregadas por la geocodificación, como se exportan los reportes nuevos.
+	backfill := `
+		UPDATE report_column rc
+		SET column_position = p.position
+		FROM (
+			SELECT report_id, name,
+				DENSE_RANK() OVER (
+					PARTITION BY report_id
+					ORDER BY array_position(ARRAY['Dirección Normalizada', 'Latitud', 'Longitud'], name) NULLS FIRST, name
+				) - 1 AS position
+			FROM (
+				SELECT DISTINCT report_id, name
+				FROM report_column
+				WHERE report_id IN (
+					SELECT report_id
+					FROM report_column
+					GROUP BY report_id
+					HAVING MAX(column_position) = 0 AND COUNT(DISTINCT name) > 1
+				)
+			) legacy
+		) p
+		WHERE rc.report_id = p.report_id AND rc.name = p.name
+	`
+	if _, err := tx.Exec(backfill); err != nil {
+		return fmt.Errorf("error backfilling report column positions: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error adding column_position to report_column: %v", err)
+	}
+	return nil
+}
+
+func (db *PortalRepository) SaveReportColumnByIdReport(reportID int, addressID int, infoReport map[string]string, columns []string, index int) (int, error) {
+	// Respetar el orden original y dejar al final las columnas no incluidas en columns
+	ordered := make([]string, 0, len(infoReport))
+	for _, name := range columns {
+		if _, ok := infoReport[name]; ok {
+			ordered = append(ordered, name)
+		}
+	}
+	var extra []string
+	for name := range infoReport {
+		if !slices.Contains(ordered, name) {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	ordered = append(ordered, extra...)
+
+	// El checkpoint del job se escribe después de guardar la fila, así que una fila reintentada
+	// tras una caída puede existir ya: se reemplaza dentro de la misma transacción.
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting report column transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	deleteQuery := `DELETE FROM report_column WHERE report_id = $1 AND index_column = $2`
+	if _, err := tx.Exec(deleteQuery, reportID, index); err != nil {
+		return 0, fmt.Errorf("error replacing report row: %v", err)
+	}
+
 	count := 0
-	for name, value := range infoReport {
-		query := `INSERT INTO report_column (report_id, id_address, name, value,index_column)
-				  VALUES ($1, $2, $3 , $4 , $5)`
-		result, err := db.Exec(query, reportID, addressID, name, value, index)
+	for position, name := range ordered {
+		query := `INSERT INTO report_column (report_id, id_address, name, value, index_column, column_position)
+				  VALUES ($1, $2, $3 , $4 , $5, $6)`
+		result, err := tx.Exec(query, reportID, addressID, name, infoReport[name], index, position)
 		if err != nil {
 			log.Printf("error saving report column: %v", err)
-			return count, fmt.Errorf("error saving report column: %v", err)
+			return 0, fmt.Errorf("error saving report column: %v", err)
 		}
 		rowsAffected, _ := result.RowsAffected()
 		count += int(rowsAffected)
 	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing report columns: %v", err)
+	}
 	return count, nil
 }
 
@@ -414,6 +508,76 @@ func (db PortalRepository) GetReportRowsByReportID(reportID int, page int, pageS
 
 	return reportRows, totalRows, nil
 }
+
+// GetReportColumns retorna las columnas del reporte en su orden original
+func (db PortalRepository) GetReportColumns(reportID int) ([]string, error) {
+	query := `
+        SELECT rc.name
+        FROM report_column rc
+        WHERE rc.report_id = $1
+        GROUP BY rc.name
+        ORDER BY MIN(rc.column_position), rc.name
+    `
+	rows, err := db.Query(query, reportID)
+	if err != nil {
+		log.Printf("Error querying report columns: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Printf("Error scanning report column: %v", err)
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// StreamReportRows recorre las filas del reporte con las coordenadas y el geocodificador de su dirección sin cargarlas en memoria
+func (db PortalRepository) StreamReportRows(reportID int, fn func(row dto.GeoReportRow) error) error {
+	query := `
+        SELECT 
+            rc.index_column,
+            json_object_agg(rc.name, rc.value) AS fila_transpuesta,
+            COALESCE(MAX(a.latitude), 0),
+            COALESCE(MAX(a.longitude), 0),
+            COALESCE(MAX(a.geocoder), '')
+        FROM report_column rc
+        LEFT JOIN address a ON a.id = rc.id_address
+        WHERE rc.report_id = $1
+        GROUP BY rc.index_column
+        ORDER BY rc.index_column
+    `
+	rows, err := db.Query(query, reportID)
+	if err != nil {
+		log.Printf("Error querying report rows: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row      dto.GeoReportRow
+			filaJSON []byte
+		)
+		if err := rows.Scan(&row.IndexColumn, &filaJSON, &row.Latitude, &row.Longitude, &row.Geocoder); err != nil {
+			log.Printf("Error scanning row: %v", err)
+			return err
+		}
+		if err := json.Unmarshal(filaJSON, &row.FilaTranspuesta); err != nil {
+			log.Printf("Error unmarshaling fila_transpuesta: %v", err)
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
 func (db *PortalRepository) GetTotalReportsAndAddress(userID int) ([]dto.CategoryCount, error) {
 	query := `
         SELECT 
@@ -658,6 +822,9 @@ func (db *PortalRepository) GetReportByReportUserID(userID, reportID int) (dto.R
 	return report, nil
 }
 
+// ErrAddressNotFound indica que no hay una dirección suficientemente similar en la base
+var ErrAddressNotFound = errors.New("dirección no encontrada")
+
 func (db *PortalRepository) FindAddress(address string) (dto.WeMapsAddress, error) {
 	var geo dto.WeMapsAddress
 	var similarityNormalized, similarityRaw float64
@@ -671,6 +838,9 @@ func (db *PortalRepository) FindAddress(address string) (dto.WeMapsAddress, erro
 		ORDER BY GREATEST(similarity($1, normalized_address), similarity($1, address)) DESC
 		LIMIT 1
 	`, address).Scan(&geo.FormattedAddress, &geo.Latitude, &geo.Longitude, &similarityNormalized, &similarityRaw)
+	if err == sql.ErrNoRows {
+		return dto.WeMapsAddress{}, ErrAddressNotFound
+	}
 	if err != nil {
 		return dto.WeMapsAddress{}, fmt.Errorf("error finding address: %v", err)
 	}
@@ -681,7 +851,7 @@ func (db *PortalRepository) FindAddress(address string) (dto.WeMapsAddress, erro
 	}
 
 	// Si ningún puntaje supera el umbral
-	return dto.WeMapsAddress{}, fmt.Errorf("puntaje de dirección muy bajo: normalized_address (%.2f), address (%.2f)", similarityNormalized, similarityRaw)
+	return dto.WeMapsAddress{}, fmt.Errorf("%w, puntaje de dirección muy bajo: normalized_address (%.2f), address (%.2f)", ErrAddressNotFound, similarityNormalized, similarityRaw)
 }
 
 func (db *PortalRepository) FindUserByID(userID int) (*User, error) {
@@ -714,7 +884,7 @@ func (db *PortalRepository) SetStatusReport(userID, reportID, status int) (dto.R
         UPDATE public.report
         SET status = $1
         WHERE id = $2 AND author = $3
-        RETURNING id, name, author, instance_hash, created_at, status
+        RETURNING id, name, created_at, status
     `
 
 	err := db.DB.QueryRow(query, status, reportID, userID).Scan(
@@ -0,0 +1,255 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Codificaciones soportadas para archivos CSV
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF8BOM     = "utf-8-bom"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// sniffRecords es la cantidad de registros que se analizan para detectar el formato
+const sniffRecords = 20
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// CSVFormat describe como leer un archivo CSV
+type CSVFormat struct {
+	Delimiter string `json:"delimiter"`
+	Encoding  string `json:"encoding"`
+	HeaderRow int    `json:"header_row"`
+}
+
+// SniffCSV detecta codificación, separador y fila de encabezado a partir de una muestra del archivo
+func SniffCSV(sample []byte) CSVFormat {
+	format := CSVFormat{Encoding: sniffEncoding(sample)}
+
+	text, err := decode(sample, format.Encoding)
+	if err != nil {
+		text = string(sample)
+	}
+	// Descartar la última línea, probablemente cortada por el tamaño de la muestra
+	if i := strings.LastIndexByte(text, '\n'); i > 0 {
+		text = text[:i+1]
+	}
+
+	format.Delimiter = sniffDelimiter(text)
+	format.HeaderRow = sniffHeaderRow(text, format.Delimiter)
+	return format
+}
+
+func sniffEncoding(sample []byte) string {
+	if bytes.HasPrefix(sample, utf8BOM) {
+		return EncodingUTF8BOM
+	}
+	// Una muestra puede cortar un caracter multibyte al final
+	valid := sample
+	for i := 0; i < utf8.UTFMax && len(valid) > 0 && !utf8.Valid(valid); i++ {
+		valid = valid[:len(valid)-1]
+	}
+	if utf8.Valid(valid) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+func sniffDelimiter(text string) string {
+	best, bestScore := ",", 0
+	for _, candidate := range []string{",", ";", "\t", "|"} {
+		counts := fieldCounts(text, candidate)
+		if len(counts) == 0 {
+			continue
+		}
+
+		// La moda de campos por registro y cuantos registros la cumplen
+		frequency := make(map[int]int)
+		mode := 0
+		for _, count := range counts {
+			frequency[count]++
+			if frequency[count] > frequency[mode] || (frequency[count] == frequency[mode] && count > mode) {
+				mode = count
+			}
+		}
+		if mode < 2 {
+			continue
+		}
+
+		score := frequency[mode]*1000/len(counts) + mode
+		if score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best
+}
+
+func fieldCounts(text, delimiter string) []int {
+	reader := newCSV(strings.NewReader(text), delimiter)
+	var counts []int
+	for len(counts) < sniffRecords {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		counts = append(counts, len(record))
+	}
+	return counts
+}
+
+func sniffHeaderRow(text, delimiter string) int {
+	reader := newCSV(strings.NewReader(text), delimiter)
+	var records [][]string
+	for len(records) < sniffRecords {
+		record, err := reader.Read()
+		if err != nil {
+			break
+		}
+		records = append(records, record)
+	}
+	return headerRow(records)
+}
+
+// headerRow retorna el registro sin valores numéricos con más columnas llenas.
+// Las exportaciones de Excel suelen traer títulos o filas vacías sobre el encabezado.
+func headerRow(records [][]string) int {
+	header, headerFilled := 0, 0
+	for i, record := range records {
+		numeric := false
+		for _, cell := range record {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(cell), 64); err == nil {
+				numeric = true
+				break
+			}
+		}
+		if !numeric && filled(record) > headerFilled {
+			header, headerFilled = i, filled(record)
+		}
+	}
+	return header
+}
+
+// uniqueColumns limpia los nombres del encabezado, nombrando las columnas vacías y numerando las repetidas
+func uniqueColumns(header []string) []string {
+	columns := make([]string, len(header))
+	seen := make(map[string]int)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = fmt.Sprintf("Columna %d", i+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s %d", name, seen[name])
+		}
+		columns[i] = name
+	}
+	return columns
+}
+
+func filled(record []string) int {
+	count := 0
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func newCSV(r io.Reader, delimiter string) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader
+}
+
+func decode(data []byte, encoding string) (string, error) {
+	reader, err := decodingReader(bytes.NewReader(data), encoding)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := io.ReadAll(reader)
+	return string(decoded), err
+}
+
+func decodingReader(r io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case EncodingUTF8, "":
+		return r, nil
+	case EncodingUTF8BOM:
+		buffered := bufio.NewReader(r)
+		if prefix, err := buffered.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+			buffered.Discard(len(utf8BOM))
+		}
+		return buffered, nil
+	case EncodingWindows1252:
+		return charmap.Windows1252.NewDecoder().Reader(r), nil
+	default:
+		return nil, fmt.Errorf("codificación no soportada: %s", encoding)
+	}
+}
+
+// CSVReader lee un archivo CSV fila a fila a partir del encabezado
+type CSVReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// NewCSVReader decodifica el archivo según el formato y lee el encabezado
+func NewCSVReader(r io.Reader, format CSVFormat) (*CSVReader, error) {
+	decoded, err := decodingReader(r, format.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newCSV(decoded, format.Delimiter)
+	for i := 0; i < format.HeaderRow; i++ {
+		if _, err := reader.Read(); err != nil {
+			return nil, fmt.Errorf("error leyendo filas previas al encabezado: %v", err)
+		}
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("el archivo no tiene encabezado")
+		}
+		return nil, fmt.Errorf("error leyendo encabezado: %v", err)
+	}
+
+	return &CSVReader{reader: reader, columns: uniqueColumns(header)}, nil
+}
+
+func (c *CSVReader) Columns() []string {
+	return c.columns
+}
+
+// Next retorna la siguiente fila con una celda por columna, o io.EOF al terminar.
+// Las filas completamente vacías se omiten.
+func (c *CSVReader) Next() ([]string, error) {
+	for {
+		record, err := c.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		if filled(record) == 0 {
+			continue
+		}
+		row := make([]string, len(c.columns))
+		copy(row, record)
+		return row, nil
+	}
+}
@@ -0,0 +1,99 @@
+package spreadsheet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSniffCSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   CSVFormat
+	}{
+		{
+			name:   "coma en utf-8",
+			sample: "Dirección,Comuna\nAv. Providencia 1234,Providencia\nPasaje Los Aromos 55,Maipú\n",
+			want:   CSVFormat{Delimiter: ",", Encoding: EncodingUTF8},
+		},
+		{
+			name:   "punto y coma con BOM",
+			sample: "\xEF\xBB\xBFDirección;Comuna\nAv. Providencia 1234, depto 5;Providencia\nPasaje Los Aromos 55;Maipú\n",
+			want:   CSVFormat{Delimiter: ";", Encoding: EncodingUTF8BOM},
+		},
+		{
+			name:   "tabulador",
+			sample: "Dirección\tComuna\tRegión\nAv. Providencia 1234\tProvidencia\tRM\n",
+			want:   CSVFormat{Delimiter: "\t", Encoding: EncodingUTF8},
+		},
+		{
+			name:   "windows-1252",
+			sample: "Direcci\xf3n|Comuna\nAv. Providencia 1234|Maip\xfa\n",
+			want:   CSVFormat{Delimiter: "|", Encoding: EncodingWindows1252},
+		},
+		{
+			name:   "título sobre el encabezado",
+			sample: "Reporte de clientes;;\n;;\nDirección;Comuna;Código\nAv. Providencia 1234;Providencia;101\nPasaje Los Aromos 55;Maipú;102\n",
+			want:   CSVFormat{Delimiter: ";", Encoding: EncodingUTF8, HeaderRow: 2},
+		},
+		{
+			name:   "muestra que corta un caracter multibyte",
+			sample: "Dirección,Comuna\nAv. Providencia 1234,Providencia\nPasaje Los Aromos 55,Maip\xc3",
+			want:   CSVFormat{Delimiter: ",", Encoding: EncodingUTF8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SniffCSV([]byte(tt.sample)); got != tt.want {
+				t.Errorf("SniffCSV() = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderRow(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		want    int
+	}{
+		{name: "encabezado en la primera fila", records: [][]string{{"Dirección", "Comuna"}, {"Av. Providencia 1234", "Providencia"}}, want: 0},
+		{name: "filas vacías antes", records: [][]string{{"", ""}, {"Dirección", "Comuna"}, {"Calle 1", "Maipú"}}, want: 1},
+		{name: "título con menos columnas", records: [][]string{{"Clientes", "", ""}, {"Dirección", "Comuna", "Región"}, {"Calle 1", "Maipú", "RM"}}, want: 1},
+		{name: "filas con números", records: [][]string{{"Dirección", "Número"}, {"Providencia", "1234"}}, want: 0},
+		{name: "sin registros", records: nil, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headerRow(tt.records); got != tt.want {
+				t.Errorf("headerRow() = %d, se esperaba %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	data := "Clientes;;\nDirección;;Dirección\nAv. Providencia 1234;Providencia;\n;;\nPasaje Los Aromos 55;Maipú;extra\n"
+	reader, err := NewCSVReader(strings.NewReader(data), CSVFormat{Delimiter: ";", Encoding: EncodingUTF8, HeaderRow: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Dirección", "Columna 2", "Dirección 2"}; !reflect.DeepEqual(reader.Columns(), want) {
+		t.Errorf("columnas = %q, se esperaba %q", reader.Columns(), want)
+	}
+
+	// La fila vacía se omite
+	var rows [][]string
+	for {
+		row, err := reader.Next()
+		if err != nil {
+			break
+		}
+		rows = append(rows, row)
+	}
+	want := [][]string{{"Av. Providencia 1234", "Providencia", ""}, {"Pasaje Los Aromos 55", "Maipú", "extra"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("filas = %q, se esperaba %q", rows, want)
+	}
+}
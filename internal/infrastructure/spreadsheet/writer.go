@@ -0,0 +1,104 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// encodingWriter codifica lo escrito en w; en windows-1252 los caracteres sin equivalente se reemplazan
+func encodingWriter(w io.Writer, enc string) (io.Writer, error) {
+	switch enc {
+	case EncodingUTF8, "":
+		return w, nil
+	case EncodingUTF8BOM:
+		if _, err := w.Write(utf8BOM); err != nil {
+			return nil, err
+		}
+		return w, nil
+	case EncodingWindows1252:
+		return encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).Writer(w), nil
+	default:
+		return nil, fmt.Errorf("codificación no soportada: %s", enc)
+	}
+}
+
+// CSVWriter escribe un CSV fila a fila con el separador y la codificación del formato
+type CSVWriter struct {
+	writer *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer, format CSVFormat) (*CSVWriter, error) {
+	encoded, err := encodingWriter(w, format.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(encoded)
+	if format.Delimiter != "" {
+		writer.Comma, _ = utf8.DecodeRuneInString(format.Delimiter)
+	}
+	// Excel espera fin de línea CRLF
+	writer.UseCRLF = true
+	return &CSVWriter{writer: writer}, nil
+}
+
+func (c *CSVWriter) WriteRow(row []string) error {
+	return c.writer.Write(row)
+}
+
+func (c *CSVWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// XLSXWriter escribe una hoja con un stream de excelize y genera el archivo al cerrar
+type XLSXWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func NewXLSXWriter(w io.Writer, sheet string) (*XLSXWriter, error) {
+	file := excelize.NewFile()
+	if sheet != "" {
+		if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("nombre de hoja inválido: %v", err)
+		}
+	}
+
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creando hoja: %v", err)
+	}
+	return &XLSXWriter{w: w, file: file, stream: stream}, nil
+}
+
+func (x *XLSXWriter) WriteRow(row []string) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	values := make([]interface{}, len(row))
+	for i, value := range row {
+		values[i] = value
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+func (x *XLSXWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("error escribiendo hoja: %v", err)
+	}
+	return x.file.Write(x.w)
+}
@@ -0,0 +1,115 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	rows := [][]string{
+		{"Dirección", "Comuna", "Latitud"},
+		{"Av. Providencia 1234; depto 5", "Maipú", "-33.426300"},
+	}
+	tests := []struct {
+		name   string
+		format CSVFormat
+		want   string
+	}{
+		{
+			name:   "coma en utf-8",
+			format: CSVFormat{Delimiter: ",", Encoding: EncodingUTF8},
+			want:   "Dirección,Comuna,Latitud\r\nAv. Providencia 1234; depto 5,Maipú,-33.426300\r\n",
+		},
+		{
+			name:   "punto y coma con BOM",
+			format: CSVFormat{Delimiter: ";", Encoding: EncodingUTF8BOM},
+			want:   "\xEF\xBB\xBFDirección;Comuna;Latitud\r\n\"Av. Providencia 1234; depto 5\";Maipú;-33.426300\r\n",
+		},
+		{
+			name:   "tabulador en windows-1252",
+			format: CSVFormat{Delimiter: "\t", Encoding: EncodingWindows1252},
+			want:   "Direcci\xf3n\tComuna\tLatitud\r\nAv. Providencia 1234; depto 5\tMaip\xfa\t-33.426300\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewCSVWriter(&buf, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, row := range rows {
+				if err := writer.WriteRow(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("CSV = %q, se esperaba %q", got, tt.want)
+			}
+			// El archivo descargado se vuelve a reconocer con el mismo formato
+			if got := SniffCSV(buf.Bytes()); got != tt.format {
+				t.Errorf("SniffCSV() = %+v, se esperaba %+v", got, tt.format)
+			}
+		})
+	}
+}
+
+func TestCSVWriterUnsupportedEncoding(t *testing.T) {
+	if _, err := NewCSVWriter(io.Discard, CSVFormat{Encoding: "latin9"}); err == nil {
+		t.Error("se esperaba error para una codificación no soportada")
+	}
+}
+
+func TestXLSXWriter(t *testing.T) {
+	rows := [][]string{
+		{"Dirección", "Comuna", "Latitud"},
+		{"Av. Providencia 1234", "Providencia", "-33.426300"},
+		{"Pasaje Los Aromos 55", "Maipú", "0.000000"},
+	}
+	path := filepath.Join(t.TempDir(), "reporte.xlsx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := NewXLSXWriter(file, "Clientes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	reader, err := NewXLSXReader(path, XLSXFormat{Sheet: "Clientes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	got := [][]string{reader.Columns()}
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, row)
+	}
+	if !reflect.DeepEqual(got, rows) {
+		t.Errorf("hoja = %q, se esperaba %q", got, rows)
+	}
+}
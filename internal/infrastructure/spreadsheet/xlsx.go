@@ -0,0 +1,189 @@
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// isoDatePattern reemplaza el formato de fecha corta regional de Excel
+const isoDatePattern = "yyyy-mm-dd"
+
+// XLSXFormat describe como leer una hoja de un libro Excel
+type XLSXFormat struct {
+	Sheet     string `json:"sheet"`
+	HeaderRow int    `json:"header_row"`
+}
+
+func openXLSX(path string) (*excelize.File, error) {
+	file, err := excelize.OpenFile(path, excelize.Options{ShortDatePattern: isoDatePattern})
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo libro Excel: %v", err)
+	}
+	return file, nil
+}
+
+// SniffXLSX valida la hoja solicitada, o usa la hoja activa del libro, y detecta la fila de encabezado
+func SniffXLSX(path, sheet string) (XLSXFormat, error) {
+	file, err := openXLSX(path)
+	if err != nil {
+		return XLSXFormat{}, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if sheet == "" {
+		sheet = file.GetSheetName(file.GetActiveSheetIndex())
+	}
+	if !slices.Contains(sheets, sheet) {
+		return XLSXFormat{}, fmt.Errorf("la hoja %q no existe, hojas disponibles: %v", sheet, sheets)
+	}
+
+	rows, err := file.Rows(sheet)
+	if err != nil {
+		return XLSXFormat{}, err
+	}
+	defer rows.Close()
+
+	merges, err := mergedRanges(file, sheet)
+	if err != nil {
+		return XLSXFormat{}, err
+	}
+
+	var records [][]string
+	for sheetRow := 1; len(records) < sniffRecords && rows.Next(); sheetRow++ {
+		record, err := rows.Columns()
+		if err != nil {
+			return XLSXFormat{}, err
+		}
+		if filled(record) > 0 {
+			records = append(records, fillMerged(record, sheetRow, merges))
+		}
+	}
+	return XLSXFormat{Sheet: sheet, HeaderRow: headerRow(records)}, nil
+}
+
+// XLSXReader lee una hoja de un libro Excel fila a fila a partir del encabezado
+type XLSXReader struct {
+	file    *excelize.File
+	rows    *excelize.Rows
+	columns []string
+}
+
+// NewXLSXReader abre la hoja y lee el encabezado. HeaderRow cuenta solo filas no vacías, igual que en CSV.
+// Las celdas combinadas del encabezado repiten su valor en todas las columnas que cubren.
+func NewXLSXReader(path string, format XLSXFormat) (*XLSXReader, error) {
+	file, err := openXLSX(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := file.Rows(format.Sheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error leyendo hoja %q: %v", format.Sheet, err)
+	}
+	reader := &XLSXReader{file: file, rows: rows}
+
+	sheetRow, header := 0, []string(nil)
+	for records := 0; records <= format.HeaderRow; {
+		if !rows.Next() {
+			reader.Close()
+			return nil, errors.New("la hoja no tiene encabezado")
+		}
+		sheetRow++
+		record, err := rows.Columns()
+		if err != nil {
+			reader.Close()
+			return nil, fmt.Errorf("error leyendo encabezado: %v", err)
+		}
+		if filled(record) > 0 {
+			header = record
+			records++
+		}
+	}
+
+	merges, err := mergedRanges(file, format.Sheet)
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+	reader.columns = uniqueColumns(fillMerged(header, sheetRow, merges))
+	return reader, nil
+}
+
+// mergedRange es un rango de celdas combinadas con su valor
+type mergedRange struct {
+	startCol, startRow, endCol, endRow int
+	value                              string
+}
+
+func mergedRanges(file *excelize.File, sheet string) ([]mergedRange, error) {
+	merges, err := file.GetMergeCells(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo celdas combinadas: %v", err)
+	}
+
+	var ranges []mergedRange
+	for _, merge := range merges {
+		startCol, startRow, err := excelize.CellNameToCoordinates(merge.GetStartAxis())
+		if err != nil {
+			continue
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(merge.GetEndAxis())
+		if err != nil {
+			continue
+		}
+		ranges = append(ranges, mergedRange{startCol, startRow, endCol, endRow, merge.GetCellValue()})
+	}
+	return ranges, nil
+}
+
+// fillMerged copia el valor de cada celda combinada a todas las columnas que cubre en la fila
+func fillMerged(record []string, sheetRow int, merges []mergedRange) []string {
+	for _, merge := range merges {
+		if sheetRow < merge.startRow || sheetRow > merge.endRow {
+			continue
+		}
+		for len(record) < merge.endCol {
+			record = append(record, "")
+		}
+		for col := merge.startCol; col <= merge.endCol; col++ {
+			record[col-1] = merge.value
+		}
+	}
+	return record
+}
+
+func (x *XLSXReader) Columns() []string {
+	return x.columns
+}
+
+// Next retorna la siguiente fila con una celda por columna, o io.EOF al terminar.
+// Las filas completamente vacías se omiten.
+func (x *XLSXReader) Next() ([]string, error) {
+	for x.rows.Next() {
+		record, err := x.rows.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if filled(record) == 0 {
+			continue
+		}
+		row := make([]string, len(x.columns))
+		copy(row, record)
+		return row, nil
+	}
+	if err := x.rows.Error(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (x *XLSXReader) Close() error {
+	x.rows.Close()
+	return x.file.Close()
+}
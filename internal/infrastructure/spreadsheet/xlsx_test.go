@@ -0,0 +1,172 @@
+package spreadsheet
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeXLSX guarda un libro con las filas de cada hoja a partir de A1; la primera hoja es Sheet1
+func writeXLSX(t *testing.T, sheets map[string][][]any, build func(file *excelize.File)) string {
+	t.Helper()
+	file := excelize.NewFile()
+	defer file.Close()
+	for sheet, rows := range sheets {
+		if sheet != "Sheet1" {
+			if _, err := file.NewSheet(sheet); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, i+1)
+			if err := file.SetSheetRow(sheet, cell, &row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if build != nil {
+		build(file)
+	}
+	path := filepath.Join(t.TempDir(), "libro.xlsx")
+	if err := file.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSniffXLSX(t *testing.T) {
+	sheets := map[string][][]any{
+		"Sheet1": {{"Dirección", "Comuna"}, {"Av. Providencia 1234", "Providencia"}},
+		"Clientes": {
+			{"Reporte de clientes"},
+			{},
+			{"Dirección", "Comuna", "Código"},
+			{"Av. Providencia 1234", "Providencia", 101},
+			{"Pasaje Los Aromos 55", "Maipú", 102},
+		},
+	}
+	activeClientes := func(file *excelize.File) {
+		index, _ := file.GetSheetIndex("Clientes")
+		file.SetActiveSheet(index)
+	}
+	tests := []struct {
+		name    string
+		sheet   string
+		build   func(file *excelize.File)
+		want    XLSXFormat
+		wantErr string
+	}{
+		{name: "hoja activa", want: XLSXFormat{Sheet: "Sheet1"}},
+		{name: "otra hoja activa con título", build: activeClientes, want: XLSXFormat{Sheet: "Clientes", HeaderRow: 1}},
+		{name: "hoja solicitada", sheet: "Clientes", want: XLSXFormat{Sheet: "Clientes", HeaderRow: 1}},
+		{name: "hoja inexistente", sheet: "Ventas", wantErr: `"Ventas" no existe`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeXLSX(t, sheets, tt.build)
+
+			got, err := SniffXLSX(path, tt.sheet)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, se esperaba que mencionara %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("SniffXLSX() = %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXLSXReader(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		rows        [][]any
+		build       func(file *excelize.File)
+		wantColumns []string
+		wantRows    [][]string
+	}{
+		{
+			name: "encabezado con celdas combinadas",
+			rows: [][]any{
+				{"Dirección", nil, "Comuna"},
+				{"Av. Providencia", "1234", "Providencia"},
+			},
+			build: func(file *excelize.File) {
+				if err := file.MergeCell("Sheet1", "A1", "B1"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantColumns: []string{"Dirección", "Dirección 2", "Comuna"},
+			wantRows:    [][]string{{"Av. Providencia", "1234", "Providencia"}},
+		},
+		{
+			name: "fecha corta en formato ISO",
+			rows: [][]any{
+				{"Dirección", "Fecha"},
+				{"Av. Providencia 1234", date},
+			},
+			build: func(file *excelize.File) {
+				// 14 es el formato de fecha corta regional de Excel
+				style, err := file.NewStyle(&excelize.Style{NumFmt: 14})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := file.SetCellStyle("Sheet1", "B2", "B2", style); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantColumns: []string{"Dirección", "Fecha"},
+			wantRows:    [][]string{{"Av. Providencia 1234", "2024-03-05"}},
+		},
+		{
+			name: "filas vacías y cortas",
+			rows: [][]any{
+				{"Dirección", "Comuna", "Código"},
+				{},
+				{"Pasaje Los Aromos 55"},
+			},
+			wantColumns: []string{"Dirección", "Comuna", "Código"},
+			wantRows:    [][]string{{"Pasaje Los Aromos 55", "", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeXLSX(t, map[string][][]any{"Sheet1": tt.rows}, tt.build)
+
+			reader, err := NewXLSXReader(path, XLSXFormat{Sheet: "Sheet1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+
+			if got := reader.Columns(); !reflect.DeepEqual(got, tt.wantColumns) {
+				t.Errorf("Columns() = %q, se esperaba %q", got, tt.wantColumns)
+			}
+			var rows [][]string
+			for {
+				row, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				rows = append(rows, row)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("filas = %q, se esperaban %q", rows, tt.wantRows)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package ports
 
-import "wemaps/internal/domain"
+import (
+	"context"
+	"wemaps/internal/domain"
+)
 
 type GeolocationService interface {
-	GetCoordsFromAddress(address string) (domain.Geolocation, error)
+	GetCoordsFromAddress(ctx context.Context, address string) (domain.Geolocation, error)
 }
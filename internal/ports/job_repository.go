@@ -0,0 +1,12 @@
+package ports
+
+import "wemaps/internal/domain"
+
+type JobRepository interface {
+	CreateJob(job domain.Job) (domain.Job, bool, error)
+	GetJob(idTask string) (domain.Job, error)
+	GetUnfinishedJobs() ([]domain.Job, error)
+	SetJobReport(idTask string, reportID int) error
+	UpdateJobStatus(idTask string, status string, recordProcess int, idError string, message string) error
+	UpdateJobProgress(idTask string, recordProcess int) error
+}
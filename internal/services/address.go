@@ -0,0 +1,172 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// defaultAddressSeparator separa las partes de la dirección cuando no se indica otro separador
+const defaultAddressSeparator = ", "
+
+// AddressMapping indica como componer la dirección a geocodificar desde varias columnas del reporte.
+// Ejemplo: calle + " " + número + ", " + comuna + ", " + "Chile".
+type AddressMapping struct {
+	Parts     []AddressPart `json:"parts"`
+	Separator *string       `json:"separator,omitempty"`
+}
+
+// AddressPart es una columna del reporte o un valor constante, como el país por defecto.
+// Separator reemplaza al separador general antes de esta parte.
+type AddressPart struct {
+	Column    string  `json:"column,omitempty"`
+	Constant  string  `json:"constant,omitempty"`
+	Separator *string `json:"separator,omitempty"`
+}
+
+// AddressMapping retorna la composición de la dirección del reporte, por defecto la primera columna
+func (r CoordsReportRequest) AddressMapping() AddressMapping {
+	if r.Address != nil && len(r.Address.Parts) > 0 {
+		return *r.Address
+	}
+	if len(r.Columns) == 0 {
+		return AddressMapping{}
+	}
+	return AddressMapping{Parts: []AddressPart{{Column: r.Columns[0]}}}
+}
+
+// Validate verifica que las columnas de la composición existan en el reporte
+func (m AddressMapping) Validate(columns []string) error {
+	if len(m.Parts) == 0 {
+		return fmt.Errorf("la dirección no tiene partes")
+	}
+	for _, part := range m.Parts {
+		if part.Column == "" && part.Constant == "" {
+			return fmt.Errorf("cada parte de la dirección debe indicar column o constant")
+		}
+		if part.Column != "" && !slices.Contains(columns, part.Column) {
+			return fmt.Errorf("la columna %q no existe en el reporte", part.Column)
+		}
+	}
+	return nil
+}
+
+// Compose arma la dirección con los valores de la fila, omitiendo las partes vacías
+func (m AddressMapping) Compose(value func(column string) string) string {
+	separator := defaultAddressSeparator
+	if m.Separator != nil {
+		separator = *m.Separator
+	}
+
+	var sb strings.Builder
+	for _, part := range m.Parts {
+		text := part.Constant
+		if part.Column != "" {
+			text = value(part.Column)
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			if part.Separator != nil {
+				sb.WriteString(*part.Separator)
+			} else {
+				sb.WriteString(separator)
+			}
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// RowComposer retorna una función que compone la dirección desde una fila con el orden de columns
+func (m AddressMapping) RowComposer(columns []string) func(row []string) string {
+	return rowComposer(columns, m.Compose)
+}
+
+// RowMapping compone lo que se geocodifica en cada fila: una dirección o un par de coordenadas
+type RowMapping interface {
+	Validate(columns []string) error
+	Compose(value func(column string) string) string
+	RowComposer(columns []string) func(row []string) string
+}
+
+// RowMapping retorna la composición de coordenadas en un reporte inverso y la de la dirección en otro caso
+func (r CoordsReportRequest) RowMapping() RowMapping {
+	if r.Reverse() {
+		return *r.Coordinates
+	}
+	return r.AddressMapping()
+}
+
+// Reverse indica si el reporte obtiene direcciones desde coordenadas
+func (r CoordsReportRequest) Reverse() bool {
+	return r.Coordinates != nil
+}
+
+// ValidateOptions revisa la precisión mínima y el modo consenso, que no se pueden combinar entre sí
+// ni con la geocodificación inversa
+func (r CoordsReportRequest) ValidateOptions() error {
+	if _, err := ParsePrecision(r.MinPrecision); err != nil {
+		return err
+	}
+	if r.Reverse() && r.MinPrecision != "" {
+		return errors.New("min_precision no se puede combinar con coordinates")
+	}
+	if r.Consensus == nil {
+		return nil
+	}
+	if r.Reverse() || r.MinPrecision != "" {
+		return errors.New("consensus no se puede combinar con coordinates ni con min_precision")
+	}
+	return r.Consensus.Validate()
+}
+
+// CoordinatesMapping indica las columnas de latitud y longitud de un reporte de geocodificación inversa
+type CoordinatesMapping struct {
+	Latitude  string `json:"latitude"`
+	Longitude string `json:"longitude"`
+}
+
+// Validate verifica que las columnas de latitud y longitud existan en el reporte
+func (m CoordinatesMapping) Validate(columns []string) error {
+	if m.Latitude == "" || m.Longitude == "" {
+		return fmt.Errorf("las coordenadas deben indicar latitude y longitude")
+	}
+	for _, column := range []string{m.Latitude, m.Longitude} {
+		if !slices.Contains(columns, column) {
+			return fmt.Errorf("la columna %q no existe en el reporte", column)
+		}
+	}
+	return nil
+}
+
+// Compose arma el par "lat,lon"; acepta coma decimal en cada columna
+func (m CoordinatesMapping) Compose(value func(column string) string) string {
+	lat := strings.Replace(strings.TrimSpace(value(m.Latitude)), ",", ".", 1)
+	lon := strings.Replace(strings.TrimSpace(value(m.Longitude)), ",", ".", 1)
+	return lat + "," + lon
+}
+
+// RowComposer retorna una función que compone las coordenadas desde una fila con el orden de columns
+func (m CoordinatesMapping) RowComposer(columns []string) func(row []string) string {
+	return rowComposer(columns, m.Compose)
+}
+
+// rowComposer adapta compose para leer los valores desde una fila con el orden de columns
+func rowComposer(columns []string, compose func(value func(column string) string) string) func(row []string) string {
+	positions := make(map[string]int, len(columns))
+	for i, col := range columns {
+		positions[col] = i
+	}
+	return func(row []string) string {
+		return compose(func(column string) string {
+			if i, ok := positions[column]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		})
+	}
+}
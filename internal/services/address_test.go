@@ -0,0 +1,91 @@
+package services
+
+import "testing"
+
+func TestValidateOptions(t *testing.T) {
+	coordinates := &CoordinatesMapping{Latitude: "Latitud", Longitude: "Longitud"}
+	tests := []struct {
+		name    string
+		report  CoordsReportRequest
+		wantErr bool
+	}{
+		{name: "sin opciones", report: CoordsReportRequest{}},
+		{name: "precisión mínima", report: CoordsReportRequest{MinPrecision: "street"}},
+		{name: "precisión inválida", report: CoordsReportRequest{MinPrecision: "city"}, wantErr: true},
+		{name: "inversa", report: CoordsReportRequest{Coordinates: coordinates}},
+		{name: "inversa con precisión mínima", report: CoordsReportRequest{Coordinates: coordinates, MinPrecision: "street"}, wantErr: true},
+		{name: "consenso", report: CoordsReportRequest{Consensus: &ConsensusOptions{Providers: 2}}},
+		{name: "consenso con precisión mínima", report: CoordsReportRequest{Consensus: &ConsensusOptions{}, MinPrecision: "street"}, wantErr: true},
+		{name: "consenso inverso", report: CoordsReportRequest{Consensus: &ConsensusOptions{}, Coordinates: coordinates}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.report.ValidateOptions(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOptions() = %v, se esperaba error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddressMappingCompose(t *testing.T) {
+	space, none := " ", ""
+	columns := []string{"Calle", "Número", "Comuna"}
+	tests := []struct {
+		name    string
+		mapping AddressMapping
+		row     []string
+		want    string
+	}{
+		{
+			name:    "separador por defecto",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Calle"}, {Column: "Comuna"}}},
+			row:     []string{"Av. Providencia", "1234", "Providencia"},
+			want:    "Av. Providencia, Providencia",
+		},
+		{
+			name:    "separador de la parte y constante",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Calle"}, {Column: "Número", Separator: &space}, {Column: "Comuna"}, {Constant: "Chile"}}},
+			row:     []string{"Av. Providencia", "1234", "Providencia"},
+			want:    "Av. Providencia 1234, Providencia, Chile",
+		},
+		{
+			name:    "separador general vacío",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Calle"}, {Column: "Número"}}, Separator: &none},
+			row:     []string{"Pasaje Los Aromos ", "55"},
+			want:    "Pasaje Los Aromos55",
+		},
+		{
+			name:    "partes vacías se omiten con su separador",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Calle"}, {Column: "Número", Separator: &space}, {Column: "Comuna"}, {Constant: "Chile"}}},
+			row:     []string{"Av. Providencia", "  ", ""},
+			want:    "Av. Providencia, Chile",
+		},
+		{
+			name:    "primera parte vacía",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Calle"}, {Column: "Número", Separator: &space}, {Column: "Comuna"}}},
+			row:     []string{"", "1234", "Maipú"},
+			want:    "1234, Maipú",
+		},
+		{
+			name:    "columna inexistente y fila corta",
+			mapping: AddressMapping{Parts: []AddressPart{{Column: "Región"}, {Column: "Calle"}, {Column: "Comuna"}}},
+			row:     []string{"Pasaje Los Aromos 55"},
+			want:    "Pasaje Los Aromos 55",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mapping.RowComposer(columns)(tt.row); got != tt.want {
+				t.Errorf("RowComposer() = %q, se esperaba %q", got, tt.want)
+			}
+
+			values := make(map[string]string)
+			for i, value := range tt.row {
+				values[columns[i]] = value
+			}
+			if got := tt.mapping.Compose(func(column string) string { return values[column] }); got != tt.want {
+				t.Errorf("Compose() = %q, se esperaba %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wemaps/internal/domain"
+)
+
+// defaultBatchWorkers es la cantidad de workers por lote si no se define GEOCODING_WORKERS
+const defaultBatchWorkers = 8
+
+// Reintentos de errores transitorios de un proveedor
+const (
+	defaultRetries   = 2
+	baseRetryBackoff = 250 * time.Millisecond
+	maxRetryBackoff  = 4 * time.Second
+)
+
+// BatchResult es el resultado de geocodificar una fila del lote.
+// Duplicate indica que la dirección ya apareció en una fila anterior y se reutilizó su resultado.
+type BatchResult struct {
+	Index     int
+	Row       []string
+	Address   string
+	Geo       domain.Geolocation
+	Err       error
+	Duplicate bool
+
+	lookup *batchLookup
+}
+
+// batchLookup es la geocodificación compartida por todas las filas con la misma dirección normalizada
+type batchLookup struct {
+	geo domain.Geolocation
+	err error
+}
+
+// GeocodeBatch geocodifica las filas con un pool acotado de workers, componiendo la dirección de cada fila con address.
+// onResult se invoca en el orden original de las filas; si retorna error el lote se detiene.
+// Las filas se leen a medida que avanzan los workers, por lo que el lote no se carga completo en memoria.
+// Cada dirección normalizada se geocodifica una sola vez y su resultado se repite en las filas duplicadas.
+// Si el primer proveedor acepta consultas por lote, los workers reciben grupos de direcciones nuevas.
+func (s *GeolocationService) GeocodeBatch(ctx context.Context, rows ReportRows, address func(row []string) string, onResult func(BatchResult) error) error {
+	return s.runBatch(ctx, rows, address, s.batchSize(), s.geocodeAddresses, onResult)
+}
+
+type rowGateKey struct{}
+
+// WithRowGate agrega al contexto del lote una espera que se consulta antes de leer cada fila, así mientras gate
+// no retorna no se inician consultas nuevas a los proveedores (por ejemplo con la carga pausada).
+// Un error de gate detiene el lote.
+func WithRowGate(ctx context.Context, gate func() error) context.Context {
+	return context.WithValue(ctx, rowGateKey{}, gate)
+}
+
+// rowGate retorna la espera de WithRowGate o una que no espera
+func rowGate(ctx context.Context) func() error {
+	if gate, ok := ctx.Value(rowGateKey{}).(func() error); ok {
+		return gate
+	}
+	return func() error { return nil }
+}
+
+// runBatch reparte las filas entre los workers en grupos de hasta chunkSize direcciones distintas que resuelve resolve
+func (s *GeolocationService) runBatch(ctx context.Context, rows ReportRows, address func(row []string) string, chunkSize int, resolve func(ctx context.Context, addresses []string) []batchLookup, onResult func(BatchResult) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	gate := rowGate(ctx)
+
+	jobs := make(chan []BatchResult)
+	results := make(chan BatchResult)
+	// window limita las filas leídas que aún no se entregan, incluidas las que esperan reordenarse
+	window := make(chan struct{}, s.workers*4*chunkSize)
+
+	var wg sync.WaitGroup
+	for range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				addresses := make([]string, len(chunk))
+				for i, job := range chunk {
+					addresses[i] = job.Address
+				}
+				lookups := resolve(ctx, addresses)
+				for i, job := range chunk {
+					*job.lookup = lookups[i]
+					job.Geo, job.Err = job.lookup.geo, job.lookup.err
+					select {
+					case results <- job:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var readErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(jobs)
+		// Solo este goroutine accede a lookups y chunk
+		lookups := make(map[string]*batchLookup)
+		var chunk []BatchResult
+		flush := func() bool {
+			if len(chunk) == 0 {
+				return true
+			}
+			select {
+			case jobs <- chunk:
+				chunk = nil
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for index := 0; ; index++ {
+			select {
+			case window <- struct{}{}:
+			default:
+				// Con la ventana llena el grupo pendiente se envía, sus filas pueden estar reteniendo a las duplicadas
+				if !flush() {
+					return
+				}
+				select {
+				case window <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			// Las filas se retienen antes de leerlas, no después de consultar al proveedor
+			if err := gate(); err != nil {
+				if ctx.Err() == nil {
+					readErr = err
+					cancel()
+				}
+				return
+			}
+			row, err := rows.Next()
+			if err == io.EOF {
+				flush()
+				return
+			}
+			if err != nil {
+				readErr = fmt.Errorf("error leyendo fila %d: %v", index+1, err)
+				cancel()
+				return
+			}
+			job := BatchResult{Index: index, Row: row, Address: address(row)}
+			key := NormalizeAddress(job.Address)
+			if lookup, ok := lookups[key]; ok {
+				// La fila original siempre se entrega antes, así que el resultado ya estará disponible
+				job.lookup, job.Duplicate = lookup, true
+				select {
+				case results <- job:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+			job.lookup = &batchLookup{}
+			lookups[key] = job.lookup
+			chunk = append(chunk, job)
+			if len(chunk) == chunkSize && !flush() {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Reordenar los resultados para entregarlos en el orden de las filas
+	pending := make(map[int]BatchResult)
+	next := 0
+	for result := range results {
+		pending[result.Index] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if ready.Duplicate {
+				ready.Geo, ready.Err = ready.lookup.geo, ready.lookup.err
+			}
+			<-window
+			// Un lote cancelado no entrega más filas aunque ya estén geocodificadas
+			if ctx.Err() != nil {
+				break
+			}
+			if err := onResult(ready); err != nil {
+				cancel()
+				return err
+			}
+		}
+	}
+
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// envInt lee un entero positivo desde una variable de entorno
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// getenv retorna la primera variable de entorno definida
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func batchWorkers() int {
+	return envInt("GEOCODING_WORKERS", defaultBatchWorkers)
+}
+
+// geocoderRate lee las consultas por segundo de GEOCODER_RATE_<NOMBRE> y la ráfaga de GEOCODER_BURST_<NOMBRE>
+func geocoderRate(name string, fallback float64, burst int) (float64, int) {
+	rps, err := strconv.ParseFloat(os.Getenv("GEOCODER_RATE_"+strings.ToUpper(name)), 64)
+	if err != nil || rps < 0 {
+		rps = fallback
+	}
+	return rps, envInt("GEOCODER_BURST_"+strings.ToUpper(name), burst)
+}
+
+// geocoderRetries lee cuantas veces se reintenta un error transitorio de GEOCODER_RETRIES_<NOMBRE> o GEOCODER_RETRIES
+func geocoderRetries(name string, fallback int) int {
+	retries, err := strconv.Atoi(getenv("GEOCODER_RETRIES_"+strings.ToUpper(name), "GEOCODER_RETRIES"))
+	if err != nil || retries < 0 {
+		return fallback
+	}
+	return retries
+}
+
+// retryBackoff duplica la espera en cada intento hasta maxRetryBackoff, con jitter para no sincronizar workers
+func retryBackoff(attempt int) time.Duration {
+	backoff := min(baseRetryBackoff<<attempt, maxRetryBackoff)
+	return backoff/2 + rand.N(backoff/2)
+}
+
+// geocoderTimeout lee el tiempo máximo por consulta de GEOCODER_TIMEOUT_<NOMBRE> (por ejemplo "5s")
+func geocoderTimeout(name string, fallback time.Duration) time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("GEOCODER_TIMEOUT_" + strings.ToUpper(name)))
+	if err != nil || timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
+
+// geocoderConcurrency define cuantas consultas simultaneas acepta cada proveedor.
+// Nominatim exige una consulta a la vez; se puede ajustar con GEOCODER_CONCURRENCY_<NOMBRE>.
+func geocoderConcurrency(name string, fallback int) int {
+	return envInt("GEOCODER_CONCURRENCY_"+strings.ToUpper(name), fallback)
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+// rowsOf arma las filas en memoria de una sola columna con las direcciones indicadas
+func rowsOf(addresses ...string) ReportRows {
+	return &memoryRows{
+		columns: []string{"Dirección"},
+		values:  map[string][]string{"Dirección": addresses},
+		total:   len(addresses),
+	}
+}
+
+func firstColumn(row []string) string {
+	return row[0]
+}
+
+// countingResolve resuelve cada dirección con su largo como latitud y cuenta las direcciones consultadas
+func countingResolve(calls *atomic.Int32) func(ctx context.Context, addresses []string) []batchLookup {
+	return func(ctx context.Context, addresses []string) []batchLookup {
+		results := make([]batchLookup, len(addresses))
+		for i, address := range addresses {
+			calls.Add(1)
+			results[i].geo = domain.Geolocation{FormattedAddress: address, Latitude: float64(len(address))}
+		}
+		return results
+	}
+}
+
+func TestRunBatchGateStopsLookups(t *testing.T) {
+	addresses := make([]string, 100)
+	for i := range addresses {
+		addresses[i] = "Calle " + strconv.Itoa(i)
+	}
+	rows := rowsOf(addresses...).(*memoryRows)
+
+	var calls atomic.Int32
+	var mu sync.Mutex
+	paused, delivered, pausedAt := false, 0, -1
+	resumed := make(chan struct{})
+	// drained se cierra cuando la carga está retenida y ya se entregaron todas las filas leídas antes de la pausa
+	drained := make(chan struct{})
+	var once sync.Once
+	checkDrained := func() {
+		if pausedAt >= 0 && delivered == pausedAt {
+			once.Do(func() { close(drained) })
+		}
+	}
+	gate := func() error {
+		mu.Lock()
+		if !paused {
+			mu.Unlock()
+			return nil
+		}
+		if pausedAt < 0 {
+			pausedAt = rows.index
+			checkDrained()
+		}
+		mu.Unlock()
+		<-resumed
+		return nil
+	}
+
+	s := &GeolocationService{workers: 4}
+	ctx := WithRowGate(context.Background(), gate)
+	done := make(chan error)
+	go func() {
+		done <- s.runBatch(ctx, rows, firstColumn, 1, countingResolve(&calls), func(BatchResult) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered++
+			if delivered == 10 {
+				paused = true
+			}
+			checkDrained()
+			return nil
+		})
+	}()
+
+	<-drained
+	// Con la carga pausada no se inician consultas nuevas aunque la ventana tenga espacio:
+	// solo se consultaron las filas leídas antes de la pausa
+	mu.Lock()
+	read := pausedAt
+	mu.Unlock()
+	if read >= len(addresses) {
+		t.Fatalf("se leyeron todas las filas antes de la pausa")
+	}
+	if got := calls.Load(); got != int32(read) {
+		t.Fatalf("se consultaron %d direcciones con %d filas leídas antes de la pausa", got, read)
+	}
+
+	close(resumed)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != int32(len(addresses)) || delivered != len(addresses) {
+		t.Errorf("consultas %d, entregadas %d, se esperaban %d", calls.Load(), delivered, len(addresses))
+	}
+}
+
+func TestRunBatchGateError(t *testing.T) {
+	var calls atomic.Int32
+	s := &GeolocationService{workers: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	gate := func() error {
+		// Una carga cancelada durante la pausa detiene el lote sin consultar más filas
+		cancel()
+		return ctx.Err()
+	}
+
+	err := s.runBatch(WithRowGate(ctx, gate), rowsOf("Calle 1", "Calle 2"), firstColumn, 1, countingResolve(&calls), func(BatchResult) error {
+		return nil
+	})
+	if err == nil || calls.Load() != 0 {
+		t.Errorf("error %v, consultas %d", err, calls.Load())
+	}
+}
+
+func TestRunBatchOrder(t *testing.T) {
+	addresses := []string{"Calle 1", "Calle 2", "Calle 3", "Calle 4", "Calle 5", "Calle 6", "Calle 7", "Calle 8"}
+	tests := []struct {
+		name      string
+		workers   int
+		chunkSize int
+	}{
+		{name: "un worker", workers: 1, chunkSize: 1},
+		{name: "varios workers", workers: 4, chunkSize: 1},
+		{name: "consultas por lote", workers: 2, chunkSize: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			resolve := countingResolve(&calls)
+			// Con varios workers el grupo de la primera dirección espera a que se resuelva otro,
+			// así los resultados llegan desordenados
+			released := make(chan struct{})
+			var once sync.Once
+			slow := func(ctx context.Context, addresses []string) []batchLookup {
+				if addresses[0] == "Calle 1" {
+					if tt.workers > 1 {
+						<-released
+					}
+					return resolve(ctx, addresses)
+				}
+				defer once.Do(func() { close(released) })
+				return resolve(ctx, addresses)
+			}
+
+			s := &GeolocationService{workers: tt.workers}
+			var delivered []BatchResult
+			err := s.runBatch(context.Background(), rowsOf(addresses...), firstColumn, tt.chunkSize, slow, func(result BatchResult) error {
+				delivered = append(delivered, result)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(delivered) != len(addresses) {
+				t.Fatalf("se entregaron %d filas de %d", len(delivered), len(addresses))
+			}
+			for i, result := range delivered {
+				if result.Index != i || result.Geo.FormattedAddress != addresses[i] {
+					t.Errorf("fila %d: índice %d, dirección %q", i, result.Index, result.Geo.FormattedAddress)
+				}
+			}
+		})
+	}
+}
+
+func TestRunBatchStopsOnResultError(t *testing.T) {
+	var calls atomic.Int32
+	s := &GeolocationService{workers: 2}
+	stop := errors.New("detener")
+	delivered := 0
+	err := s.runBatch(context.Background(), rowsOf("Calle 1", "Calle 2", "Calle 3", "Calle 4"), firstColumn, 1, countingResolve(&calls), func(BatchResult) error {
+		delivered++
+		if delivered == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || delivered != 2 {
+		t.Errorf("error %v después de %d filas", err, delivered)
+	}
+}
+
+func TestRunBatchDuplicates(t *testing.T) {
+	addresses := []string{"Calle 1", "Calle 2", "calle  1", "Calle 3", "CALLE 2", "Calle 4", "Calle 1", "Calle 5"}
+	wantDuplicate := []bool{false, false, true, false, true, false, true, false}
+	// Cada fila repetida recibe el resultado de la primera fila con su dirección
+	wantAddress := []string{"Calle 1", "Calle 2", "Calle 1", "Calle 3", "Calle 2", "Calle 4", "Calle 1", "Calle 5"}
+
+	tests := []struct {
+		name      string
+		workers   int
+		chunkSize int
+	}{
+		{name: "un worker", workers: 1, chunkSize: 1},
+		{name: "varios workers", workers: 4, chunkSize: 1},
+		{name: "consultas por lote", workers: 2, chunkSize: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			s := &GeolocationService{workers: tt.workers}
+			var delivered []BatchResult
+			err := s.runBatch(context.Background(), rowsOf(addresses...), firstColumn, tt.chunkSize, countingResolve(&calls), func(result BatchResult) error {
+				delivered = append(delivered, result)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls.Load() != 5 {
+				t.Errorf("se consultaron %d direcciones, se esperaban 5 distintas", calls.Load())
+			}
+			if len(delivered) != len(addresses) {
+				t.Fatalf("se entregaron %d filas de %d", len(delivered), len(addresses))
+			}
+			for i, result := range delivered {
+				if result.Index != i || result.Duplicate != wantDuplicate[i] || result.Geo.FormattedAddress != wantAddress[i] {
+					t.Errorf("fila %d: índice %d, duplicada %v, dirección %q", i, result.Index, result.Duplicate, result.Geo.FormattedAddress)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+)
+
+// Estados del circuit breaker de un proveedor
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+const (
+	defaultBreakerFailures = 5
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// errCircuitOpen se retorna sin consultar al proveedor mientras su circuito está abierto
+var errCircuitOpen = errors.New("proveedor suspendido temporalmente por errores consecutivos")
+
+// circuitBreaker deja de consultar un proveedor tras threshold errores seguidos y lo vuelve a
+// probar con una sola consulta pasado cooldown. Una dirección no encontrada no cuenta como error.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+}
+
+func newCircuitBreaker(name string) *circuitBreaker {
+	upper := strings.ToUpper(name)
+	cooldown, err := time.ParseDuration(getenv("GEOCODER_BREAKER_COOLDOWN_"+upper, "GEOCODER_BREAKER_COOLDOWN"))
+	if err != nil || cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	threshold := envInt("GEOCODER_BREAKER_FAILURES_"+upper, envInt("GEOCODER_BREAKER_FAILURES", defaultBreakerFailures))
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: breakerClosed}
+}
+
+// allow indica si se puede consultar al proveedor; en half-open solo pasa la consulta de prueba
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// release libera la consulta de prueba cuando quien consultaba la abandonó; no dice nada del proveedor
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record registra el resultado de una consulta permitida por allow
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+
+	// Una dirección no encontrada o inexacta significa que el proveedor responde bien
+	if kind := geocoders.Classify(err); kind == "" || kind == geocoders.KindNotFound || kind == geocoders.KindNotExact {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *circuitBreaker) health(name string) domain.GeocoderHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := domain.GeocoderHealth{
+		Name:      name,
+		State:     b.state,
+		Failures:  b.failures,
+		LastError: b.lastError,
+	}
+	if b.state == breakerOpen {
+		retryAt := b.openedAt.Add(b.cooldown)
+		health.RetryAt = &retryAt
+	}
+	return health
+}
+
+// GeocoderHealth retorna el estado del circuit breaker de cada proveedor de la cadena
+func (s *GeolocationService) GeocoderHealth() []domain.GeocoderHealth {
+	health := make([]domain.GeocoderHealth, len(s.geocoders))
+	for i, entry := range s.geocoders {
+		health[i] = entry.breaker.health(entry.name)
+	}
+	return health
+}
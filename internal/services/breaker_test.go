@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	transient := &geocoders.GeocodeError{Kind: geocoders.KindTransient, Err: errors.New("503")}
+	quota := &geocoders.GeocodeError{Kind: geocoders.KindAuthQuota, Err: errors.New("402")}
+
+	// Los pasos son: "allow" y "deny" revisan allow, "expire" cumple el cooldown, "release" abandona la prueba
+	// y los errores se registran con record
+	tests := []struct {
+		name      string
+		steps     []any
+		wantState string
+	}{
+		{name: "errores bajo el umbral", steps: []any{transient, transient, "allow"}, wantState: breakerClosed},
+		{name: "se abre al umbral", steps: []any{transient, quota, transient, "deny"}, wantState: breakerOpen},
+		{name: "un éxito reinicia la cuenta", steps: []any{transient, transient, nil, transient, transient, "allow"}, wantState: breakerClosed},
+		{name: "no encontrada no es falla", steps: []any{transient, transient, geocoders.ErrNotFound, geocoders.ErrNotExact, transient, "allow"}, wantState: breakerClosed},
+		{name: "prueba tras el cooldown", steps: []any{transient, transient, transient, "expire", "allow", "deny"}, wantState: breakerHalfOpen},
+		{name: "prueba exitosa cierra", steps: []any{transient, transient, transient, "expire", "allow", nil, "allow", "allow"}, wantState: breakerClosed},
+		{name: "prueba fallida reabre", steps: []any{transient, transient, transient, "expire", "allow", transient, "deny"}, wantState: breakerOpen},
+		{name: "prueba abandonada", steps: []any{transient, transient, transient, "expire", "allow", "release", "allow", "deny"}, wantState: breakerHalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &circuitBreaker{threshold: 3, cooldown: time.Hour, state: breakerClosed}
+			for i, step := range tt.steps {
+				switch step {
+				case "allow", "deny":
+					if allowed := b.allow(); allowed != (step == "allow") {
+						t.Fatalf("paso %d: allow() = %v en estado %s", i, allowed, b.state)
+					}
+				case "expire":
+					b.openedAt = time.Now().Add(-b.cooldown)
+				case "release":
+					b.release()
+				default:
+					err, _ := step.(error)
+					b.record(err)
+				}
+			}
+			if b.state != tt.wantState {
+				t.Errorf("estado = %s, se esperaba %s", b.state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHealth(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, cooldown: time.Minute, state: breakerClosed}
+	b.record(&geocoders.GeocodeError{Kind: geocoders.KindTransient, Err: errors.New("timeout")})
+
+	health := b.health("google")
+	if health.State != breakerOpen || health.Failures != 1 || health.LastError == "" {
+		t.Fatalf("health = %+v", health)
+	}
+	if health.RetryAt == nil || !health.RetryAt.Equal(b.openedAt.Add(time.Minute)) {
+		t.Errorf("RetryAt = %v", health.RetryAt)
+	}
+}
+
+// failingGeocoder falla con errs en orden y luego entrega un resultado
+type failingGeocoder struct {
+	errs  []error
+	calls int
+}
+
+func (g *failingGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	g.calls++
+	if g.calls <= len(g.errs) {
+		return nil, g.errs[g.calls-1]
+	}
+	return &domain.Geolocation{FormattedAddress: address, Precision: domain.PrecisionRooftop}, nil
+}
+
+func TestGeocoderEntryRetries(t *testing.T) {
+	transient := &geocoders.GeocodeError{Kind: geocoders.KindTransient, Err: errors.New("503")}
+	quota := &geocoders.GeocodeError{Kind: geocoders.KindAuthQuota, Err: errors.New("402")}
+	tests := []struct {
+		name         string
+		errs         []error
+		wantCalls    int
+		wantKind     geocoders.ErrorKind
+		wantFailures int
+	}{
+		{name: "transitorio se reintenta", errs: []error{transient}, wantCalls: 2},
+		{name: "transitorio agota los reintentos", errs: []error{transient, transient}, wantCalls: 2, wantKind: geocoders.KindTransient, wantFailures: 1},
+		{name: "no encontrada no se reintenta", errs: []error{geocoders.ErrNotFound}, wantCalls: 1, wantKind: geocoders.KindNotFound},
+		{name: "cuota no se reintenta", errs: []error{quota}, wantCalls: 1, wantKind: geocoders.KindAuthQuota, wantFailures: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geocoder := &failingGeocoder{errs: tt.errs}
+			entry := newGeocoderEntry("failing", geocoder, geocoderLimits{concurrency: 1, retries: 1, minScore: 0})
+			entry.breaker = &circuitBreaker{threshold: 3, cooldown: time.Hour, state: breakerClosed}
+
+			_, err := entry.Geocode(context.Background(), "Avenida Providencia 1234")
+			if geocoder.calls != tt.wantCalls {
+				t.Errorf("consultas = %d, se esperaban %d", geocoder.calls, tt.wantCalls)
+			}
+			if kind := geocoders.Classify(err); kind != tt.wantKind {
+				t.Errorf("error %v de tipo %q, se esperaba %q", err, kind, tt.wantKind)
+			}
+			// El breaker solo registra el resultado final, no cada intento
+			if entry.breaker.failures != tt.wantFailures {
+				t.Errorf("fallas del breaker = %d, se esperaban %d", entry.breaker.failures, tt.wantFailures)
+			}
+		})
+	}
+}
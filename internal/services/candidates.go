@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+)
+
+// candidatesCachePrefix separa en el cache los resultados con candidatos de los resultados exactos
+const candidatesCachePrefix = "CANDIDATES "
+
+// ParsePrecision valida la precisión mínima pedida; vacía significa usar la geocodificación exacta
+func ParsePrecision(value string) (domain.Precision, error) {
+	precision := domain.Precision(value)
+	if value != "" && precision.Level() == 0 {
+		return "", fmt.Errorf("precisión inválida %q, se espera rooftop, interpolated, street o locality", value)
+	}
+	return precision, nil
+}
+
+// GetCandidates geocodifica la dirección aceptando resultados con precisión minPrecision o mejor.
+// Retorna el mejor candidato del primer proveedor que tenga alguno y en Alternatives los demás que cumplen,
+// así una dirección ambigua entrega alternativas en vez de fallar.
+func (s *GeolocationService) GetCandidates(ctx context.Context, address string, minPrecision domain.Precision) (domain.Geolocation, error) {
+	formattedAddress := formatAddress(address)
+	key := candidatesCachePrefix + string(minPrecision) + " " + formattedAddress
+	geo, err := s.shared(ctx, key, func() (domain.Geolocation, error) {
+		return s.candidatesLookup(ctx, address, formattedAddress, minPrecision, key)
+	})
+	if err == nil {
+		geo.OriginAddress = address
+	}
+	return geo, err
+}
+
+// candidatesLookup consulta el cache y luego los candidatos de cada proveedor en orden
+func (s *GeolocationService) candidatesLookup(ctx context.Context, address, formattedAddress string, minPrecision domain.Precision, key string) (domain.Geolocation, error) {
+	result, exists, err := s.repository.Get(ctx, key)
+	if err != nil {
+		return domain.Geolocation{}, err
+	}
+	// El cache también debe cumplir la coincidencia y la precisión mínimas, pueden haber cambiado desde que se guardó
+	if exists && s.cachedMatch(formattedAddress, &result) && result.Precision.AtLeast(minPrecision) {
+		return result, nil
+	}
+
+	var failures chainFailures
+	for _, geocoder := range s.geocoders {
+		candidates, err := geocoder.Candidates(ctx, formattedAddress)
+		if ctx.Err() != nil {
+			return domain.Geolocation{}, ctx.Err()
+		}
+		if err == nil {
+			candidates = filterPrecision(candidates, minPrecision)
+			if len(candidates) == 0 {
+				err = fmt.Errorf("%w: ningún candidato con precisión %s o mejor", geocoders.ErrNotExact, minPrecision)
+			}
+		}
+		if err != nil {
+			failures.add(geocoder.name, err)
+			continue
+		}
+
+		best := candidates[0]
+		for _, alternative := range candidates[1:] {
+			// La respuesta completa del proveedor ya queda en el mejor candidato
+			alternative.ResponseCoordsApi = nil
+			best.Alternatives = append(best.Alternatives, alternative)
+		}
+		return s.store(ctx, address, key, &best)
+	}
+	return domain.Geolocation{}, failures.err()
+}
+
+// filterPrecision conserva en orden los candidatos con precisión minPrecision o mejor
+func filterPrecision(candidates []domain.Geolocation, minPrecision domain.Precision) []domain.Geolocation {
+	var accepted []domain.Geolocation
+	for _, candidate := range candidates {
+		if candidate.Precision.AtLeast(minPrecision) {
+			accepted = append(accepted, candidate)
+		}
+	}
+	return accepted
+}
+
+// GeocodeBatchCandidates geocodifica las filas como GeocodeBatch pero con GetCandidates y la precisión mínima indicada
+func (s *GeolocationService) GeocodeBatchCandidates(ctx context.Context, rows ReportRows, address func(row []string) string, minPrecision domain.Precision, onResult func(BatchResult) error) error {
+	return s.runBatch(ctx, rows, address, 1, func(ctx context.Context, addresses []string) []batchLookup {
+		results := make([]batchLookup, len(addresses))
+		for i, address := range addresses {
+			results[i].geo, results[i].err = s.GetCandidates(ctx, address, minPrecision)
+		}
+		return results
+	}, onResult)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"wemaps/internal/domain"
+)
+
+// consensusCachePrefix separa en el cache los resultados comparados entre proveedores
+const consensusCachePrefix = "CONSENSUS "
+
+const (
+	defaultConsensusProviders = 2
+	defaultConsensusMeters    = 100
+)
+
+// ConsensusOptions activa el modo consenso: la dirección se consulta en varios proveedores y se comparan sus resultados
+type ConsensusOptions struct {
+	// Providers es la cantidad de proveedores con resultado a comparar, 2 por defecto
+	Providers int `json:"providers,omitempty"`
+	// Meters es la distancia máxima entre resultados para considerarlos la misma ubicación, 100 por defecto
+	Meters float64 `json:"meters,omitempty"`
+}
+
+func (o ConsensusOptions) withDefaults() ConsensusOptions {
+	if o.Providers == 0 {
+		o.Providers = defaultConsensusProviders
+	}
+	if o.Meters == 0 {
+		o.Meters = defaultConsensusMeters
+	}
+	return o
+}
+
+// Validate revisa que haya al menos dos proveedores que comparar y una distancia positiva
+func (o ConsensusOptions) Validate() error {
+	if o.Providers < 0 || o.Providers == 1 {
+		return fmt.Errorf("consenso: providers debe ser al menos 2")
+	}
+	if o.Meters < 0 {
+		return fmt.Errorf("consenso: meters no puede ser negativo")
+	}
+	return nil
+}
+
+// GetConsensus geocodifica la dirección en los proveedores de la cadena, en orden, hasta reunir options.Providers
+// resultados y los compara. El resultado es el que más proveedores respaldan; si alguno queda a más de
+// options.Meters la dirección queda en disputa y los resultados distintos se conservan en Alternatives.
+func (s *GeolocationService) GetConsensus(ctx context.Context, address string, options ConsensusOptions) (domain.Geolocation, error) {
+	options = options.withDefaults()
+	formattedAddress := formatAddress(address)
+	key := fmt.Sprintf("%s%d %g %s", consensusCachePrefix, options.Providers, options.Meters, formattedAddress)
+	geo, err := s.shared(ctx, key, func() (domain.Geolocation, error) {
+		return s.consensusLookup(ctx, address, formattedAddress, options, key)
+	})
+	if err == nil {
+		geo.OriginAddress = address
+	}
+	return geo, err
+}
+
+// consensusLookup consulta el cache y luego los proveedores hasta tener los resultados a comparar
+func (s *GeolocationService) consensusLookup(ctx context.Context, address, formattedAddress string, options ConsensusOptions, key string) (domain.Geolocation, error) {
+	result, exists, err := s.repository.Get(ctx, key)
+	if err != nil {
+		return domain.Geolocation{}, err
+	}
+	if exists && s.cachedMatch(formattedAddress, &result) {
+		return result, nil
+	}
+
+	var failures chainFailures
+	var results []providerResult
+	for _, geocoder := range s.geocoders {
+		if len(results) == options.Providers {
+			break
+		}
+		geo, err := geocoder.Geocode(ctx, formattedAddress)
+		if ctx.Err() != nil {
+			return domain.Geolocation{}, ctx.Err()
+		}
+		if err != nil {
+			failures.add(geocoder.name, err)
+			continue
+		}
+		results = append(results, providerResult{name: geocoder.name, geo: *geo})
+	}
+	if len(results) == 0 {
+		return domain.Geolocation{}, failures.err()
+	}
+
+	geo := compareResults(results, options.Meters)
+	return s.store(ctx, address, key, &geo)
+}
+
+// providerResult es el resultado de un proveedor de la cadena identificado por su nombre en la configuración
+type providerResult struct {
+	name string
+	geo  domain.Geolocation
+}
+
+// compareResults elige el resultado que más proveedores respaldan dentro de meters, ante empate el primero de
+// la cadena, y deja en Alternatives los resultados que no coinciden con él
+func compareResults(results []providerResult, meters float64) domain.Geolocation {
+	best, bestAgreeing := 0, 0
+	for i, a := range results {
+		agreeing := 0
+		for _, b := range results {
+			if distanceMeters(a.geo, b.geo) <= meters {
+				agreeing++
+			}
+		}
+		if agreeing > bestAgreeing {
+			best, bestAgreeing = i, agreeing
+		}
+	}
+
+	geo := results[best].geo
+	consensus := &domain.Consensus{Status: domain.ConsensusSingle, Agreeing: []string{results[best].name}}
+	for i, other := range results {
+		if i == best {
+			continue
+		}
+		distance := distanceMeters(geo, other.geo)
+		consensus.Distance = math.Max(consensus.Distance, math.Round(distance))
+		if distance <= meters {
+			consensus.Agreeing = append(consensus.Agreeing, other.name)
+			continue
+		}
+		consensus.Disagreeing = append(consensus.Disagreeing, other.name)
+		// La respuesta completa del proveedor ya queda en el resultado
+		other.geo.ResponseCoordsApi = nil
+		geo.Alternatives = append(geo.Alternatives, other.geo)
+	}
+	switch {
+	case len(consensus.Disagreeing) > 0:
+		consensus.Status = domain.ConsensusDisputed
+	case len(consensus.Agreeing) > 1:
+		consensus.Status = domain.ConsensusConfirmed
+	}
+	geo.Consensus = consensus
+	return geo
+}
+
+// distanceMeters es la distancia haversine entre dos resultados
+func distanceMeters(a, b domain.Geolocation) float64 {
+	const earthRadius = 6371000
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// GeocodeBatchConsensus geocodifica las filas como GeocodeBatch pero comparando proveedores con GetConsensus
+func (s *GeolocationService) GeocodeBatchConsensus(ctx context.Context, rows ReportRows, address func(row []string) string, options ConsensusOptions, onResult func(BatchResult) error) error {
+	return s.runBatch(ctx, rows, address, 1, func(ctx context.Context, addresses []string) []batchLookup {
+		results := make([]batchLookup, len(addresses))
+		for i, address := range addresses {
+			results[i].geo, results[i].err = s.GetConsensus(ctx, address, options)
+		}
+		return results
+	}, onResult)
+}
+
+// ConsensusColumns retorna los valores de las columnas del modo consenso, vacíos si no se comparó
+func ConsensusColumns(consensus *domain.Consensus) map[string]string {
+	columns := map[string]string{ColumnConsensus: "", ColumnAgreeing: "", ColumnDisagreeing: ""}
+	if consensus != nil {
+		columns[ColumnConsensus] = consensus.Status
+		columns[ColumnAgreeing] = strings.Join(consensus.Agreeing, ", ")
+		columns[ColumnDisagreeing] = strings.Join(consensus.Disagreeing, ", ")
+	}
+	return columns
+}
+
+// ParseConsensusColumns reconstruye la comparación desde las columnas guardadas de una fila
+func ParseConsensusColumns(row map[string]string) *domain.Consensus {
+	if row[ColumnConsensus] == "" {
+		return nil
+	}
+	consensus := &domain.Consensus{Status: row[ColumnConsensus]}
+	if agreeing := row[ColumnAgreeing]; agreeing != "" {
+		consensus.Agreeing = strings.Split(agreeing, ", ")
+	}
+	if disagreeing := row[ColumnDisagreeing]; disagreeing != "" {
+		consensus.Disagreeing = strings.Split(disagreeing, ", ")
+	}
+	return consensus
+}
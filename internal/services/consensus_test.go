@@ -0,0 +1,91 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestCompareResults(t *testing.T) {
+	// Providencia y un punto a unos 55 metros; Maipú queda a más de 10 km
+	providencia := domain.Geolocation{FormattedAddress: "Providencia", Latitude: -33.4263, Longitude: -70.6109, ResponseCoordsApi: []interface{}{"respuesta"}}
+	nearby := domain.Geolocation{FormattedAddress: "Providencia cercana", Latitude: -33.4268, Longitude: -70.6109, ResponseCoordsApi: []interface{}{"respuesta"}}
+	maipu := domain.Geolocation{FormattedAddress: "Maipú", Latitude: -33.5106, Longitude: -70.7572, ResponseCoordsApi: []interface{}{"respuesta"}}
+
+	tests := []struct {
+		name         string
+		results      []providerResult
+		wantAddress  string
+		wantStatus   string
+		agreeing     []string
+		disagreeing  []string
+		alternatives int
+	}{
+		{
+			name:        "un solo proveedor",
+			results:     []providerResult{{name: "google", geo: providencia}},
+			wantAddress: "Providencia",
+			wantStatus:  domain.ConsensusSingle,
+			agreeing:    []string{"google"},
+		},
+		{
+			name:        "proveedores de acuerdo",
+			results:     []providerResult{{name: "google", geo: providencia}, {name: "nominatim", geo: nearby}},
+			wantAddress: "Providencia",
+			wantStatus:  domain.ConsensusConfirmed,
+			agreeing:    []string{"google", "nominatim"},
+		},
+		{
+			name:         "empate, gana el primero de la cadena",
+			results:      []providerResult{{name: "google", geo: maipu}, {name: "nominatim", geo: providencia}},
+			wantAddress:  "Maipú",
+			wantStatus:   domain.ConsensusDisputed,
+			agreeing:     []string{"google"},
+			disagreeing:  []string{"nominatim"},
+			alternatives: 1,
+		},
+		{
+			name:         "gana la ubicación con más respaldo",
+			results:      []providerResult{{name: "google", geo: maipu}, {name: "nominatim", geo: providencia}, {name: "photon", geo: nearby}},
+			wantAddress:  "Providencia",
+			wantStatus:   domain.ConsensusDisputed,
+			agreeing:     []string{"nominatim", "photon"},
+			disagreeing:  []string{"google"},
+			alternatives: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geo := compareResults(tt.results, 100)
+			if geo.FormattedAddress != tt.wantAddress || geo.Consensus.Status != tt.wantStatus {
+				t.Fatalf("resultado %q con estado %q", geo.FormattedAddress, geo.Consensus.Status)
+			}
+			if !reflect.DeepEqual(geo.Consensus.Agreeing, tt.agreeing) || !reflect.DeepEqual(geo.Consensus.Disagreeing, tt.disagreeing) {
+				t.Errorf("de acuerdo %v, en desacuerdo %v", geo.Consensus.Agreeing, geo.Consensus.Disagreeing)
+			}
+			if len(geo.Alternatives) != tt.alternatives {
+				t.Fatalf("alternativas = %d, se esperaban %d", len(geo.Alternatives), tt.alternatives)
+			}
+			for _, alternative := range geo.Alternatives {
+				if alternative.ResponseCoordsApi != nil {
+					t.Error("la alternativa no debería repetir la respuesta del proveedor")
+				}
+			}
+			if tt.wantStatus == domain.ConsensusDisputed && geo.Consensus.Distance < 10000 {
+				t.Errorf("distancia = %v, se esperaba la de Maipú a Providencia", geo.Consensus.Distance)
+			}
+		})
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	a := domain.Geolocation{Latitude: -33.4263, Longitude: -70.6109}
+	b := domain.Geolocation{Latitude: -33.4268, Longitude: -70.6109}
+	if d := distanceMeters(a, a); d != 0 {
+		t.Errorf("distancia a sí mismo = %v", d)
+	}
+	// 0,0005 grados de latitud son unos 55,6 metros
+	if d := distanceMeters(a, b); d < 55 || d > 56 {
+		t.Errorf("distancia = %v, se esperaban unos 55,6 metros", d)
+	}
+}
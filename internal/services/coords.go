@@ -3,16 +3,30 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"slices"
 	"strings"
+	"time"
 	"wemaps/internal/domain"
 	"wemaps/internal/infrastructure/geocoders"
 	"wemaps/internal/ports"
+
+	"golang.org/x/sync/singleflight"
 )
 
 type CoordsReportRequest struct {
 	ReportName string              `json:"report_name"`
 	Columns    []string            `json:"columns"`
 	Values     map[string][]string `json:"values"`
+	Source     *ReportSource       `json:"source,omitempty"`
+	Address    *AddressMapping     `json:"address,omitempty"`
+	// Coordinates cambia el reporte a geocodificación inversa desde columnas de latitud y longitud
+	Coordinates *CoordinatesMapping `json:"coordinates,omitempty"`
+	// MinPrecision acepta resultados menos exactos (street, locality) y guarda las alternativas de direcciones ambiguas
+	MinPrecision string `json:"min_precision,omitempty"`
+	// Consensus consulta varios proveedores por dirección y marca las filas en que no coinciden
+	Consensus *ConsensusOptions `json:"consensus,omitempty"`
 }
 
 type CoordsResponse struct {
@@ -23,50 +37,404 @@ type CoordsResponse struct {
 	Format  string  `json:"format"`
 }
 
+// geocoderEntry limita las consultas simultaneas que recibe cada proveedor, reintenta sus errores
+// transitorios y lo suspende si falla seguido
+type geocoderEntry struct {
+	name string
+	// provider es el tipo de proveedor de la configuración, el mismo que informa Geolocation.Geocoder
+	provider string
+	geocoder geocoders.Geocoder
+	slots    chan struct{}
+	breaker  *circuitBreaker
+	retries  int
+	// batchSize es la cantidad de direcciones por consulta si el proveedor acepta lotes
+	batchSize int
+	// reverse indica si el proveedor admite geocodificación inversa
+	reverse bool
+	// candidates indica si el proveedor entrega candidatos ordenados
+	candidates bool
+	// minScore es la coincidencia mínima con la dirección ingresada para aceptar un resultado
+	minScore float64
+}
+
+// geocoderLimits son la concurrencia, el límite de consultas por segundo, los reintentos y la coincidencia mínima de un proveedor
+type geocoderLimits struct {
+	concurrency int
+	rateLimit   float64
+	burst       int
+	retries     int
+	batchSize   int
+	minScore    float64
+}
+
+// newGeocoderEntry aplica los límites del proveedor; las variables GEOCODER_*_<NOMBRE> tienen prioridad.
+// rateLimit <= 0 deja al proveedor sin límite.
+func newGeocoderEntry(name string, geocoder geocoders.Geocoder, limits geocoderLimits) geocoderEntry {
+	rps, burst := geocoderRate(name, limits.rateLimit, limits.burst)
+	_, reverse := geocoder.(geocoders.ReverseGeocoder)
+	_, candidates := geocoder.(geocoders.CandidateGeocoder)
+	return geocoderEntry{
+		name:     name,
+		geocoder: geocoders.NewRateLimitedGeocoder(geocoder, rps, burst),
+		slots:    make(chan struct{}, geocoderConcurrency(name, limits.concurrency)),
+		breaker:  newCircuitBreaker(name),
+		retries:  geocoderRetries(name, limits.retries),
+		// GEOCODER_BATCH_SIZE_<NOMBRE>=1 desactiva las consultas por lote
+		batchSize:  envInt("GEOCODER_BATCH_SIZE_"+strings.ToUpper(name), limits.batchSize),
+		reverse:    reverse,
+		candidates: candidates,
+		minScore:   geocoderMinScore(name, limits.minScore),
+	}
+}
+
+// Geocode consulta al proveedor; los errores transitorios se reintentan con backoff antes de
+// pasar al siguiente proveedor y el breaker solo registra el resultado final.
+// Un resultado que no coincide con la dirección ingresada se rechaza como no exacto.
+func (e geocoderEntry) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	var geo *domain.Geolocation
+	err := e.call(ctx, func() (err error) {
+		geo, err = e.geocoder.Geocode(ctx, address)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if geo == nil {
+		return nil, geocoders.ErrNotFound
+	}
+	if err := e.checkMatch(address, geo); err != nil {
+		return nil, err
+	}
+	return geo, nil
+}
+
+// batch retorna el proveedor si acepta consultas por lote de más de una dirección
+func (e geocoderEntry) batch() (geocoders.BatchGeocoder, bool) {
+	batch, ok := e.geocoder.(geocoders.BatchGeocoder)
+	return batch, ok && e.batchSize > 1
+}
+
+// GeocodeBatch consulta las direcciones en una sola llamada con los mismos límites, reintentos y breaker que Geocode;
+// los errores de cada dirección, incluidos los resultados que no coinciden, no cuentan como fallas del proveedor.
+func (e geocoderEntry) GeocodeBatch(ctx context.Context, addresses []string) ([]geocoders.BatchResult, error) {
+	batch, ok := e.batch()
+	if !ok {
+		return nil, fmt.Errorf("%s no acepta consultas por lote", e.name)
+	}
+	var results []geocoders.BatchResult
+	err := e.call(ctx, func() (err error) {
+		results, err = batch.GeocodeBatch(ctx, addresses)
+		if err == nil && len(results) != len(addresses) {
+			err = fmt.Errorf("%s retornó %d resultados para %d direcciones", e.name, len(results), len(addresses))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		if results[i].Err == nil && results[i].Geo != nil {
+			results[i].Err = e.checkMatch(addresses[i], results[i].Geo)
+		}
+	}
+	return results, nil
+}
+
+// ReverseGeocode obtiene la dirección de las coordenadas con los mismos límites, reintentos y breaker que Geocode
+func (e geocoderEntry) ReverseGeocode(ctx context.Context, lat, lon float64) (*domain.Geolocation, error) {
+	reverse, ok := e.geocoder.(geocoders.ReverseGeocoder)
+	if !e.reverse || !ok {
+		return nil, fmt.Errorf("%s no admite geocodificación inversa", e.name)
+	}
+	var geo *domain.Geolocation
+	err := e.call(ctx, func() (err error) {
+		geo, err = reverse.ReverseGeocode(ctx, lat, lon)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return geo, nil
+}
+
+// Candidates retorna los candidatos del proveedor; si no los entrega, el resultado exacto de Geocode es el único candidato
+func (e geocoderEntry) Candidates(ctx context.Context, address string) ([]domain.Geolocation, error) {
+	provider, ok := e.geocoder.(geocoders.CandidateGeocoder)
+	if !e.candidates || !ok {
+		geo, err := e.Geocode(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		return []domain.Geolocation{*geo}, nil
+	}
+	var candidates []domain.Geolocation
+	err := e.call(ctx, func() (err error) {
+		candidates, err = provider.Candidates(ctx, address)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Se descartan los candidatos que no coinciden con la dirección ingresada
+	var matched []domain.Geolocation
+	for _, candidate := range candidates {
+		if err = e.checkMatch(address, &candidate); err == nil {
+			matched = append(matched, candidate)
+		}
+	}
+	if len(matched) == 0 && err == nil {
+		err = geocoders.ErrNotFound
+	}
+	if len(matched) == 0 {
+		return nil, err
+	}
+	return matched, nil
+}
+
+// call ejecuta la consulta al proveedor aplicando el breaker y reintentando los errores transitorios
+func (e geocoderEntry) call(ctx context.Context, query func() error) error {
+	if !e.breaker.allow() {
+		return &geocoders.GeocodeError{Kind: geocoders.KindTransient, Err: errCircuitOpen}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := e.attempt(ctx, query)
+		if ctx.Err() != nil {
+			e.breaker.release()
+			return ctx.Err()
+		}
+		if attempt >= e.retries || geocoders.Classify(err) != geocoders.KindTransient {
+			e.breaker.record(err)
+			return err
+		}
+
+		log.Printf("Reintentando %s (%d/%d) por error transitorio: %v", e.name, attempt+1, e.retries, err)
+		timer := time.NewTimer(retryBackoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			e.breaker.release()
+			return ctx.Err()
+		}
+	}
+}
+
+// attempt ocupa un cupo de concurrencia solo mientras dura la consulta, no durante el backoff
+func (e geocoderEntry) attempt(ctx context.Context, query func() error) error {
+	select {
+	case e.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-e.slots }()
+	return query()
+}
+
 type GeolocationService struct {
-	geocoders  []geocoders.Geocoder
+	geocoders  []geocoderEntry
 	repository ports.GeolocationRepository
+	workers    int
+	// lookups agrupa las consultas simultaneas de una misma dirección normalizada o par de coordenadas
+	lookups singleflight.Group
 }
 
-func NewGeolocationService(repo ports.GeolocationRepository, portalRepo ports.PortalRepository) *GeolocationService {
+// NewGeolocationService crea el servicio con la cadena de proveedores ya validada por LoadGeocodersConfig
+func NewGeolocationService(repo ports.GeolocationRepository, portalRepo ports.PortalRepository, config GeocodersConfig) *GeolocationService {
 	return &GeolocationService{
-		geocoders: []geocoders.Geocoder{
-			geocoders.NewWemapsGeocoder(portalRepo),
-			geocoders.NewNominatimGeocoder(),
-			geocoders.NewGoogleGeocoder(),
-		},
+		geocoders:  newGeocoderChain(config, portalRepo),
 		repository: repo,
+		workers:    batchWorkers(),
 	}
 }
 
-func (s *GeolocationService) GetCoordsFromAddress(address string) (domain.Geolocation, error) {
+// GetCoordsFromAddress geocodifica la dirección; las consultas simultaneas de la misma dirección
+// comparten una sola búsqueda en cache y proveedores, y reciben el mismo resultado o error.
+// Cancelar ctx abandona la espera; si se cancela quien inició la búsqueda compartida, otro la reintenta.
+func (s *GeolocationService) GetCoordsFromAddress(ctx context.Context, address string) (domain.Geolocation, error) {
 	formattedAddress := formatAddress(address)
+	geo, err := s.shared(ctx, formattedAddress, func() (domain.Geolocation, error) {
+		return s.lookup(ctx, address, formattedAddress)
+	})
+	if err == nil {
+		geo.OriginAddress = address
+	}
+	return geo, err
+}
 
-	// Consultar en MongoDB primero
-	result, exists, err := s.repository.Get(context.Background(), formattedAddress)
+// shared ejecuta lookup una sola vez para las consultas simultaneas con la misma llave
+func (s *GeolocationService) shared(ctx context.Context, key string, lookup func() (domain.Geolocation, error)) (domain.Geolocation, error) {
+	for {
+		shared := s.lookups.DoChan(key, func() (interface{}, error) {
+			return lookup()
+		})
+
+		var result singleflight.Result
+		select {
+		case result = <-shared:
+		case <-ctx.Done():
+			return domain.Geolocation{}, ctx.Err()
+		}
+		leaderCancelled := errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded)
+		if leaderCancelled && ctx.Err() == nil {
+			continue
+		}
+
+		return result.Val.(domain.Geolocation), result.Err
+	}
+}
+
+// lookup consulta el cache y luego los geocodificadores en orden
+func (s *GeolocationService) lookup(ctx context.Context, address, formattedAddress string) (domain.Geolocation, error) {
+	// Consultar en MongoDB primero; un resultado que ya no alcanza la coincidencia mínima se vuelve a consultar
+	result, exists, err := s.repository.Get(ctx, formattedAddress)
 	if err != nil {
 		return domain.Geolocation{}, err
 	}
-	if exists {
+	if exists && s.cachedMatch(formattedAddress, &result) {
 		return result, nil
 	}
 
 	// Si no está en MongoDB, consultar los geocodificadores
-	for _, geocoder := range s.geocoders {
-		addressCoords, err := geocoder.Geocode(formattedAddress)
-		if err == nil && addressCoords != nil {
-			addressCoords.OriginAddress = address
-			// Guardar en MongoDB
-			if err := s.repository.Save(context.Background(), formattedAddress, *addressCoords); err != nil {
-				return domain.Geolocation{}, err
-			}
-			return *addressCoords, nil
+	return s.geocodeChain(ctx, address, formattedAddress, s.geocoders, chainFailures{})
+}
+
+// geocodeChain consulta los proveedores de chain en orden; failures trae los errores de los proveedores ya consultados
+func (s *GeolocationService) geocodeChain(ctx context.Context, address, formattedAddress string, chain []geocoderEntry, failures chainFailures) (domain.Geolocation, error) {
+	for _, geocoder := range chain {
+		addressCoords, err := geocoder.Geocode(ctx, formattedAddress)
+		if ctx.Err() != nil {
+			// Una búsqueda cancelada no sigue consumiendo cuota en los demás proveedores
+			return domain.Geolocation{}, ctx.Err()
+		}
+		if err == nil && addressCoords == nil {
+			err = geocoders.ErrNotFound
+		}
+		if err != nil {
+			failures.add(geocoder.name, err)
+			continue
+		}
+		return s.store(ctx, address, formattedAddress, addressCoords)
+	}
+	return domain.Geolocation{}, failures.err()
+}
+
+// store guarda en MongoDB el resultado de un proveedor
+func (s *GeolocationService) store(ctx context.Context, address, formattedAddress string, geo *domain.Geolocation) (domain.Geolocation, error) {
+	geo.OriginAddress = address
+	if err := s.repository.Save(ctx, formattedAddress, *geo); err != nil {
+		return domain.Geolocation{}, err
+	}
+	return *geo, nil
+}
+
+// geocodeAddresses geocodifica direcciones distintas entre sí. Si el primer proveedor de la cadena acepta lotes,
+// las direcciones que no están en cache se le envían en una sola consulta y las que no resuelve siguen por el
+// resto de la cadena; de lo contrario cada dirección se geocodifica por separado.
+func (s *GeolocationService) geocodeAddresses(ctx context.Context, addresses []string) []batchLookup {
+	results := make([]batchLookup, len(addresses))
+	if s.batchSize() == 1 || len(addresses) == 1 {
+		for i, address := range addresses {
+			results[i].geo, results[i].err = s.GetCoordsFromAddress(ctx, address)
+		}
+		return results
+	}
+
+	// Las direcciones en cache no se envían al proveedor
+	var pending []int
+	var queries []string
+	for i, address := range addresses {
+		formattedAddress := formatAddress(address)
+		geo, exists, err := s.repository.Get(ctx, formattedAddress)
+		switch {
+		case err != nil:
+			results[i].err = err
+		case exists && s.cachedMatch(formattedAddress, &geo):
+			geo.OriginAddress = address
+			results[i].geo = geo
+		default:
+			pending = append(pending, i)
+			queries = append(queries, formattedAddress)
+		}
+	}
+	if len(pending) == 0 {
+		return results
+	}
+
+	entry := s.geocoders[0]
+	answers, err := entry.GeocodeBatch(ctx, queries)
+	for j, i := range pending {
+		if ctx.Err() != nil {
+			results[i].err = ctx.Err()
+			continue
+		}
+		answer := geocoders.BatchResult{Err: err}
+		if err == nil {
+			answer = answers[j]
+		}
+		if answer.Err == nil && answer.Geo == nil {
+			answer.Err = geocoders.ErrNotFound
 		}
+		if answer.Err == nil {
+			results[i].geo, results[i].err = s.store(ctx, addresses[i], queries[j], answer.Geo)
+			continue
+		}
+
+		var failures chainFailures
+		failures.add(entry.name, answer.Err)
+		results[i].geo, results[i].err = s.geocodeChain(ctx, addresses[i], queries[j], s.geocoders[1:], failures)
+	}
+	return results
+}
+
+// batchSize es la cantidad de direcciones que se agrupan por consulta; 1 si el primer proveedor no acepta lotes
+func (s *GeolocationService) batchSize() int {
+	if len(s.geocoders) == 0 {
+		return 1
+	}
+	if _, ok := s.geocoders[0].batch(); !ok {
+		return 1
 	}
+	return s.geocoders[0].batchSize
+}
+
+// chainFailures acumula los errores de los proveedores consultados para una dirección
+type chainFailures struct {
+	messages []string
+	kinds    []geocoders.ErrorKind
+}
+
+func (f *chainFailures) add(name string, err error) {
+	f.messages = append(f.messages, name+": "+err.Error())
+	f.kinds = append(f.kinds, geocoders.Classify(err))
+}
+
+func (f chainFailures) err() error {
+	return &geocoders.GeocodeError{
+		Kind: addressErrorKind(f.kinds),
+		Err:  fmt.Errorf("no se pudo geolocalizar la dirección (%s)", strings.Join(f.messages, "; ")),
+	}
+}
+
+// addressErrorKind resume los errores de la cadena priorizando lo que dicen de la dirección
+// por sobre las fallas de los proveedores
+func addressErrorKind(kinds []geocoders.ErrorKind) geocoders.ErrorKind {
+	for _, kind := range []geocoders.ErrorKind{geocoders.KindNotExact, geocoders.KindNotFound, geocoders.KindTransient, geocoders.KindAuthQuota} {
+		if slices.Contains(kinds, kind) {
+			return kind
+		}
+	}
+	return geocoders.KindUnknown
+}
 
-	return domain.Geolocation{}, errors.New("no se pudo geolocalizar la dirección")
+// NormalizeAddress retorna la llave con la que se agrupan las direcciones repetidas de un reporte:
+// en mayúsculas y con espacios simples
+func NormalizeAddress(address string) string {
+	return strings.Join(strings.Fields(strings.ToUpper(address)), " ")
 }
 
+// formatAddress es la llave del cache
 func formatAddress(address string) string {
 	return strings.TrimSpace(strings.ToUpper(address))
 }
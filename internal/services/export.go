@@ -0,0 +1,159 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"wemaps/internal/adapters/http/dto"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/gis"
+	"wemaps/internal/infrastructure/spreadsheet"
+)
+
+// ExportReport escribe el reporte en el formato GIS indicado a medida que se leen las filas.
+// Todas las columnas del reporte se exportan como propiedades de cada feature.
+func (s *PortalService) ExportReport(w io.Writer, reportID int, reportName, format string) error {
+	columns, err := s.repository.GetReportColumns(reportID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo columnas del reporte: %v", err)
+	}
+
+	writer, err := gis.NewWriter(format, w, reportName, columns)
+	if err != nil {
+		return err
+	}
+
+	err = s.repository.StreamReportRows(reportID, func(row dto.GeoReportRow) error {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = row.FilaTranspuesta[column]
+		}
+		return writer.WriteFeature(gis.Feature{
+			Name:      featureName(row.FilaTranspuesta, columns),
+			Latitude:  row.Latitude,
+			Longitude: row.Longitude,
+			Values:    values,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("error exportando reporte: %v", err)
+	}
+	return writer.Close()
+}
+
+// featureName usa la dirección normalizada y, si no se geocodificó, la primera columna
+func featureName(row map[string]string, columns []string) string {
+	if name := row[GeocodingColumns[0]]; name != "" && name != "-" {
+		return name
+	}
+	if len(columns) > 0 {
+		return row[columns[0]]
+	}
+	return ""
+}
+
+// GeocodingColumns son las columnas que la geocodificación agrega a cada fila del reporte
+var GeocodingColumns = []string{"Dirección Normalizada", "Latitud", "Longitud", ColumnErrorKind, ColumnPrecision, ColumnAlternatives, ColumnMatchScore,
+	ColumnConsensus, ColumnAgreeing, ColumnDisagreeing}
+
+// ColumnErrorKind guarda la clasificación del error de las filas que no se geocodificaron
+const ColumnErrorKind = "Tipo Error"
+
+// ColumnPrecision guarda el nivel de detalle del resultado y ColumnAlternatives los otros candidatos de una dirección ambigua
+const (
+	ColumnPrecision    = "Precisión"
+	ColumnAlternatives = "Alternativas"
+)
+
+// Columnas del modo consenso: el estado y los proveedores que coinciden o no con el resultado
+const (
+	ColumnConsensus   = "Consenso"
+	ColumnAgreeing    = "Proveedores de Acuerdo"
+	ColumnDisagreeing = "Proveedores en Desacuerdo"
+)
+
+// ColumnMatchScore guarda la coincidencia entre la dirección de la fila y la del resultado
+const ColumnMatchScore = "Coincidencia"
+
+// FormatMatchScore muestra la coincidencia con dos decimales; vacía si no se calculó (geocodificación inversa)
+func FormatMatchScore(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", score)
+}
+
+// FormatAlternatives resume los candidatos alternativos como "dirección (lat, lon)" separados por punto y coma
+func FormatAlternatives(alternatives []domain.Geolocation) string {
+	parts := make([]string, len(alternatives))
+	for i, alternative := range alternatives {
+		parts[i] = fmt.Sprintf("%s (%f, %f)", alternative.FormattedAddress, alternative.Latitude, alternative.Longitude)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Columnas que la descarga agrega a partir de la dirección geocodificada
+const (
+	columnGeocoder = "Geocodificador"
+	columnStatus   = "Estado"
+)
+
+// ExportSpreadsheet escribe el reporte como CSV o XLSX con las columnas originales en su orden
+// seguidas de las de geocodificación, el geocodificador y el estado de cada fila.
+func (s *PortalService) ExportSpreadsheet(w io.Writer, reportID int, sourceType string, format spreadsheet.CSVFormat) error {
+	stored, err := s.repository.GetReportColumns(reportID)
+	if err != nil {
+		return fmt.Errorf("error obteniendo columnas del reporte: %v", err)
+	}
+
+	// Los reportes antiguos no guardan la posición, las columnas agregadas se dejan siempre al final
+	var columns []string
+	for _, column := range stored {
+		if !slices.Contains(GeocodingColumns, column) {
+			columns = append(columns, column)
+		}
+	}
+	header := slices.Concat(columns, GeocodingColumns, []string{columnGeocoder, columnStatus})
+
+	var writer interface {
+		WriteRow(row []string) error
+		Close() error
+	}
+	switch sourceType {
+	case SourceCSV:
+		writer, err = spreadsheet.NewCSVWriter(w, format)
+	case SourceXLSX:
+		writer, err = spreadsheet.NewXLSXWriter(w, "Reporte")
+	default:
+		err = fmt.Errorf("formato de descarga no soportado: %s", sourceType)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteRow(header); err != nil {
+		return fmt.Errorf("error escribiendo encabezado: %v", err)
+	}
+	err = s.repository.StreamReportRows(reportID, func(row dto.GeoReportRow) error {
+		values := make([]string, len(header))
+		for i, column := range header[:len(header)-2] {
+			values[i] = row.FilaTranspuesta[column]
+		}
+		values[len(header)-2] = row.Geocoder
+		values[len(header)-1] = rowStatus(row)
+		return writer.WriteRow(values)
+	})
+	if err != nil {
+		return fmt.Errorf("error exportando reporte: %v", err)
+	}
+	return writer.Close()
+}
+
+// rowStatus indica si la fila quedó asociada a una dirección geocodificada
+func rowStatus(row dto.GeoReportRow) string {
+	if row.Geocoder == "" {
+		return "Sin resultado"
+	}
+	return "OK"
+}
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"wemaps/internal/infrastructure/geocoders"
+	"wemaps/internal/ports"
+)
+
+// GeocoderConfig configura un proveedor de la cadena de geocodificación.
+// Los campos vacíos usan los valores por defecto del tipo de proveedor.
+type GeocoderConfig struct {
+	Name string `json:"name"`
+	// Type es el proveedor (wemaps, nominatim, google, opencage, locationiq, geoapify, geocodio, photon, pelias);
+	// por defecto es Name
+	Type    string `json:"type,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+	// APIKey admite variables de entorno, por ejemplo "${GOOGLE_API_KEY}"
+	APIKey      string   `json:"api_key,omitempty"`
+	BaseURL     string   `json:"base_url,omitempty"`
+	ReverseURL  string   `json:"reverse_url,omitempty"`
+	Accept      []string `json:"accept,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	Concurrency int      `json:"concurrency,omitempty"`
+	RateLimit   *float64 `json:"rate_limit,omitempty"`
+	Burst       int      `json:"burst,omitempty"`
+	Retries     *int     `json:"retries,omitempty"`
+	// BatchSize es la cantidad de direcciones por consulta en proveedores que aceptan lotes
+	BatchSize int `json:"batch_size,omitempty"`
+	// MinScore es la coincidencia mínima (0 a 1) entre la dirección ingresada y el resultado; 0 la desactiva
+	MinScore *float64 `json:"min_score,omitempty"`
+}
+
+// GeocodersConfig es la cadena de proveedores en el orden en que se consultan
+type GeocodersConfig struct {
+	Geocoders []GeocoderConfig `json:"geocoders"`
+}
+
+// geocoderProvider describe un tipo de proveedor y sus valores por defecto
+type geocoderProvider struct {
+	requiresKey bool
+	// requiresURL indica un servicio propio sin endpoint público por defecto
+	requiresURL bool
+	concurrency int
+	rateLimit   float64
+	batchSize   int
+	build       func(options geocoders.Options, portalRepo ports.PortalRepository) geocoders.Geocoder
+}
+
+var geocoderProviders = map[string]geocoderProvider{
+	"wemaps": {
+		concurrency: defaultBatchWorkers,
+		build: func(_ geocoders.Options, portalRepo ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewWemapsGeocoder(portalRepo)
+		},
+	},
+	"nominatim": {
+		// La política de uso de Nominatim permite una consulta por segundo
+		concurrency: 1,
+		rateLimit:   1,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewNominatimGeocoder(options)
+		},
+	},
+	"google": {
+		requiresKey: true,
+		concurrency: defaultBatchWorkers,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewGoogleGeocoder(options)
+		},
+	},
+	"opencage": {
+		// El plan gratuito de OpenCage permite una consulta por segundo
+		requiresKey: true,
+		concurrency: 1,
+		rateLimit:   1,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewOpenCageGeocoder(options)
+		},
+	},
+	"locationiq": {
+		// El plan gratuito de LocationIQ permite dos consultas por segundo
+		requiresKey: true,
+		concurrency: 2,
+		rateLimit:   2,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewLocationIQGeocoder(options)
+		},
+	},
+	"geoapify": {
+		// El plan gratuito de Geoapify permite cinco consultas por segundo
+		requiresKey: true,
+		concurrency: 5,
+		rateLimit:   5,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewGeoapifyGeocoder(options)
+		},
+	},
+	"geocodio": {
+		// Geocodio acepta hasta 10.000 direcciones por lote; lotes chicos mantienen acotada la ventana de filas
+		requiresKey: true,
+		concurrency: 2,
+		batchSize:   100,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewGeocodioGeocoder(options)
+		},
+	},
+	"photon": {
+		// Instancia propia, los límites dependen del servidor
+		requiresURL: true,
+		concurrency: defaultBatchWorkers,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewPhotonGeocoder(options)
+		},
+	},
+	"pelias": {
+		requiresURL: true,
+		concurrency: defaultBatchWorkers,
+		build: func(options geocoders.Options, _ ports.PortalRepository) geocoders.Geocoder {
+			return geocoders.NewPeliasGeocoder(options)
+		},
+	},
+}
+
+// LoadGeocodersConfig lee la cadena desde el archivo JSON indicado (o GEOCODERS_CONFIG),
+// luego desde GEOCODER_CHAIN ("google,nominatim") y si no hay ninguno usa Wemaps → Nominatim → Google.
+// La configuración se valida antes de retornarla.
+func LoadGeocodersConfig(path string) (GeocodersConfig, error) {
+	var config GeocodersConfig
+	if path == "" {
+		path = os.Getenv("GEOCODERS_CONFIG")
+	}
+
+	switch chain := os.Getenv("GEOCODER_CHAIN"); {
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("error leyendo configuración de geocodificadores: %v", err)
+		}
+		// Una clave mal escrita, como "timout", se rechaza en vez de dejar al proveedor con los valores por defecto
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("error decodificando configuración de geocodificadores: %v", err)
+		}
+	case chain != "":
+		for _, name := range strings.Split(chain, ",") {
+			config.Geocoders = append(config.Geocoders, envGeocoderConfig(strings.TrimSpace(name)))
+		}
+	default:
+		config = defaultGeocodersConfig()
+	}
+
+	for i := range config.Geocoders {
+		config.Geocoders[i].APIKey = os.ExpandEnv(config.Geocoders[i].APIKey)
+	}
+	return config, config.Validate()
+}
+
+// envGeocoderConfig arma la configuración de un proveedor desde GEOCODER_<NOMBRE>_API_KEY, _BASE_URL, _REVERSE_URL y _ACCEPT
+func envGeocoderConfig(name string) GeocoderConfig {
+	prefix := "GEOCODER_" + strings.ToUpper(name) + "_"
+	config := GeocoderConfig{
+		Name:       name,
+		APIKey:     os.Getenv(prefix + "API_KEY"),
+		BaseURL:    os.Getenv(prefix + "BASE_URL"),
+		ReverseURL: os.Getenv(prefix + "REVERSE_URL"),
+	}
+	if name == "google" && config.APIKey == "" {
+		config.APIKey = os.Getenv("GOOGLE_API_KEY")
+	}
+	if accept := os.Getenv(prefix + "ACCEPT"); accept != "" {
+		config.Accept = strings.Split(accept, ",")
+	}
+	return config
+}
+
+// defaultGeocodersConfig es la cadena histórica; Google solo se incluye si hay API key
+func defaultGeocodersConfig() GeocodersConfig {
+	config := GeocodersConfig{Geocoders: []GeocoderConfig{
+		envGeocoderConfig("wemaps"),
+		envGeocoderConfig("nominatim"),
+	}}
+	if google := envGeocoderConfig("google"); google.APIKey != "" {
+		config.Geocoders = append(config.Geocoders, google)
+	} else {
+		log.Printf("GOOGLE_API_KEY no definida, Google queda fuera de la cadena de geocodificadores")
+	}
+	return config
+}
+
+// Validate revisa que la cadena tenga al menos un proveedor habilitado y que cada uno sea utilizable
+func (c GeocodersConfig) Validate() error {
+	var errs []error
+	names := make(map[string]bool)
+	enabled := 0
+	for i, geocoder := range c.Geocoders {
+		if geocoder.Name == "" {
+			errs = append(errs, fmt.Errorf("geocodificador %d: falta name", i+1))
+			continue
+		}
+		if names[geocoder.Name] {
+			errs = append(errs, fmt.Errorf("geocodificador %s: nombre repetido", geocoder.Name))
+		}
+		names[geocoder.Name] = true
+		if !geocoder.enabled() {
+			continue
+		}
+		enabled++
+
+		provider, ok := geocoderProviders[geocoder.providerType()]
+		if !ok {
+			errs = append(errs, fmt.Errorf("geocodificador %s: tipo desconocido %q", geocoder.Name, geocoder.providerType()))
+			continue
+		}
+		if provider.requiresKey && geocoder.APIKey == "" {
+			errs = append(errs, fmt.Errorf("geocodificador %s: requiere api_key", geocoder.Name))
+		}
+		if provider.requiresURL && geocoder.BaseURL == "" {
+			errs = append(errs, fmt.Errorf("geocodificador %s: requiere base_url", geocoder.Name))
+		}
+		if geocoder.BaseURL != "" {
+			if u, err := url.Parse(geocoder.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("geocodificador %s: base_url inválida %q", geocoder.Name, geocoder.BaseURL))
+			}
+		}
+		if geocoder.Timeout != "" {
+			if timeout, err := time.ParseDuration(geocoder.Timeout); err != nil || timeout <= 0 {
+				errs = append(errs, fmt.Errorf("geocodificador %s: timeout inválido %q", geocoder.Name, geocoder.Timeout))
+			}
+		}
+		if geocoder.Concurrency < 0 || geocoder.Burst < 0 || geocoder.BatchSize < 0 || (geocoder.RateLimit != nil && *geocoder.RateLimit < 0) || (geocoder.Retries != nil && *geocoder.Retries < 0) {
+			errs = append(errs, fmt.Errorf("geocodificador %s: concurrency, rate_limit, burst, retries y batch_size no pueden ser negativos", geocoder.Name))
+		}
+		if geocoder.MinScore != nil && (*geocoder.MinScore < 0 || *geocoder.MinScore > 1) {
+			errs = append(errs, fmt.Errorf("geocodificador %s: min_score debe estar entre 0 y 1", geocoder.Name))
+		}
+	}
+	if enabled == 0 {
+		errs = append(errs, errors.New("la cadena de geocodificadores no tiene proveedores habilitados"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c GeocoderConfig) enabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+func (c GeocoderConfig) providerType() string {
+	if c.Type != "" {
+		return c.Type
+	}
+	return c.Name
+}
+
+// newGeocoderChain crea las entradas habilitadas en el orden de la configuración
+func newGeocoderChain(config GeocodersConfig, portalRepo ports.PortalRepository) []geocoderEntry {
+	var chain []geocoderEntry
+	for _, geocoder := range config.Geocoders {
+		if !geocoder.enabled() {
+			continue
+		}
+		provider := geocoderProviders[geocoder.providerType()]
+
+		timeout, _ := time.ParseDuration(geocoder.Timeout)
+		options := geocoders.Options{
+			APIKey:     geocoder.APIKey,
+			BaseURL:    geocoder.BaseURL,
+			ReverseURL: geocoder.ReverseURL,
+			Accept:     geocoder.Accept,
+			Timeout:    geocoderTimeout(geocoder.Name, timeout),
+		}
+
+		limits := geocoderLimits{
+			concurrency: provider.concurrency,
+			rateLimit:   provider.rateLimit,
+			burst:       max(geocoder.Burst, 1),
+			retries:     defaultRetries,
+			batchSize:   provider.batchSize,
+			minScore:    defaultMinMatchScore,
+		}
+		if geocoder.Concurrency > 0 {
+			limits.concurrency = geocoder.Concurrency
+		}
+		if geocoder.RateLimit != nil {
+			limits.rateLimit = *geocoder.RateLimit
+		}
+		if geocoder.Retries != nil {
+			limits.retries = *geocoder.Retries
+		}
+		if geocoder.BatchSize > 0 {
+			limits.batchSize = geocoder.BatchSize
+		}
+		if geocoder.MinScore != nil {
+			limits.minScore = *geocoder.MinScore
+		}
+
+		log.Printf("Geocodificador %d: %s (%s)", len(chain)+1, geocoder.Name, geocoder.providerType())
+		entry := newGeocoderEntry(geocoder.Name, provider.build(options, portalRepo), limits)
+		entry.provider = geocoder.providerType()
+		chain = append(chain, entry)
+	}
+	return chain
+}
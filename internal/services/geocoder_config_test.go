@@ -0,0 +1,75 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadGeocodersConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "configuración válida", config: `{"geocoders":[{"name":"nominatim","timeout":"5s","retries":1}]}`},
+		{name: "campo mal escrito", config: `{"geocoders":[{"name":"nominatim","timout":"5s"}]}`, wantErr: `"timout"`},
+		{name: "campo desconocido en la raíz", config: `{"geocoders":[{"name":"nominatim"}],"retries_max":3}`, wantErr: `"retries_max"`},
+		{name: "json inválido", config: `{"geocoders":[`, wantErr: "error decodificando"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "geocoders.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadGeocodersConfig(path)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error %v, se esperaba que mencionara %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeocodersConfigValidate(t *testing.T) {
+	disabled := false
+	negative := -1.0
+	tooHigh := 1.5
+	tests := []struct {
+		name    string
+		config  []GeocoderConfig
+		wantErr string
+	}{
+		{name: "cadena válida", config: []GeocoderConfig{{Name: "nominatim"}, {Name: "google", APIKey: "clave"}}},
+		{name: "tipo con otro nombre", config: []GeocoderConfig{{Name: "osm", Type: "nominatim", BaseURL: "http://nominatim.local/search"}}},
+		{name: "sin proveedores", wantErr: "no tiene proveedores habilitados"},
+		{name: "todos deshabilitados", config: []GeocoderConfig{{Name: "nominatim", Enabled: &disabled}}, wantErr: "no tiene proveedores habilitados"},
+		{name: "sin nombre", config: []GeocoderConfig{{Name: "nominatim"}, {Type: "google"}}, wantErr: "geocodificador 2: falta name"},
+		{name: "nombre repetido", config: []GeocoderConfig{{Name: "nominatim"}, {Name: "nominatim"}}, wantErr: "nombre repetido"},
+		{name: "tipo desconocido", config: []GeocoderConfig{{Name: "bing"}}, wantErr: `tipo desconocido "bing"`},
+		{name: "falta api_key", config: []GeocoderConfig{{Name: "opencage"}}, wantErr: "requiere api_key"},
+		{name: "falta base_url", config: []GeocoderConfig{{Name: "photon"}}, wantErr: "requiere base_url"},
+		{name: "base_url sin esquema", config: []GeocoderConfig{{Name: "photon", BaseURL: "photon.local/api"}}, wantErr: "base_url inválida"},
+		{name: "timeout inválido", config: []GeocoderConfig{{Name: "nominatim", Timeout: "5"}}, wantErr: "timeout inválido"},
+		{name: "límite negativo", config: []GeocoderConfig{{Name: "nominatim", RateLimit: &negative}}, wantErr: "no pueden ser negativos"},
+		{name: "min_score fuera de rango", config: []GeocoderConfig{{Name: "nominatim", MinScore: &tooHigh}}, wantErr: "min_score debe estar entre 0 y 1"},
+		// Un proveedor deshabilitado no necesita estar completo
+		{name: "deshabilitado incompleto", config: []GeocoderConfig{{Name: "nominatim"}, {Name: "geocodio", Enabled: &disabled}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := GeocodersConfig{Geocoders: tt.config}.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error %v, se esperaba que mencionara %q", err, tt.wantErr)
+			}
+		})
+	}
+}
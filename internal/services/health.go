@@ -2,12 +2,15 @@ package services
 
 import "wemaps/internal/domain"
 
-type Health struct{}
+type Health struct {
+	geolocation *GeolocationService
+}
 
-func NewHealthService() *Health {
-	return &Health{}
+func NewHealthService(geolocation *GeolocationService) *Health {
+	return &Health{geolocation: geolocation}
 }
 
-func (h *Health) Check(alias string ) (domain.HealthStatus, error) {
-	return domain.HealthStatus{Status: "ok " + alias}, nil
+// Check incluye el estado del circuit breaker de cada geocodificador
+func (h *Health) Check(alias string) (domain.HealthStatus, error) {
+	return domain.HealthStatus{Status: "ok " + alias, Geocoders: h.geolocation.GeocoderHealth()}, nil
 }
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemaps/internal/adapters/http/dto"
+	"wemaps/internal/domain"
+	"wemaps/internal/ports"
+)
+
+// JobService administra las tareas de carga persistidas (ver "Carga ETL" en el README)
+type JobService struct {
+	repository ports.JobRepository
+}
+
+// NewJobService crea un nuevo JobService
+func NewJobService(repository ports.JobRepository) *JobService {
+	return &JobService{repository: repository}
+}
+
+// Trigger gatilla la tarea para la definicion del reporte.
+// Si la misma definicion tiene una tarea vigente del usuario se retorna esa tarea y false;
+// una definicion cuya tarea ya terminó puede volver a gatillarse.
+func (s *JobService) Trigger(userID int, sessionID string, report CoordsReportRequest) (domain.Job, bool, error) {
+	definition, err := json.Marshal(report)
+	if err != nil {
+		return domain.Job{}, false, fmt.Errorf("failed to marshal job definition: %v", err)
+	}
+
+	definitionHash := sha256.Sum256(definition)
+	idTask := sha256.Sum256(append([]byte(strconv.FormatInt(time.Now().UnixNano(), 10)), definition...))
+
+	return s.repository.CreateJob(domain.Job{
+		IdTask:         hex.EncodeToString(idTask[:]),
+		DefinitionHash: hex.EncodeToString(definitionHash[:]),
+		Definition:     string(definition),
+		SessionID:      sessionID,
+		UserID:         userID,
+		ReportName:     report.ReportName,
+		Status:         domain.JobStatusInitial,
+		TotalRecords:   report.TotalRows(),
+	})
+}
+
+// GetJob obtiene la tarea validando que pertenezca al usuario
+func (s *JobService) GetJob(userID int, idTask string) (domain.Job, error) {
+	job, err := s.repository.GetJob(idTask)
+	if err != nil {
+		return domain.Job{}, err
+	}
+	if job.UserID != userID {
+		return domain.Job{}, fmt.Errorf("job %s not found", idTask)
+	}
+	return job, nil
+}
+
+// Unfinished retorna las tareas que deben retomarse al iniciar el servidor
+func (s *JobService) Unfinished() ([]domain.Job, error) {
+	return s.repository.GetUnfinishedJobs()
+}
+
+func (s *JobService) SetReport(idTask string, reportID int) {
+	if err := s.repository.SetJobReport(idTask, reportID); err != nil {
+		log.Printf("Error linking job %s to report %d: %v", idTask, reportID, err)
+	}
+}
+
+// Progress marca la tarea en proceso con la cantidad de registros procesados, salvo que se haya pausado o cancelado
+func (s *JobService) Progress(idTask string, recordProcess int) {
+	if err := s.repository.UpdateJobProgress(idTask, recordProcess); err != nil {
+		log.Printf("Error updating job %s progress: %v", idTask, err)
+	}
+}
+
+// Resume vuelve a marcar en proceso una tarea pausada
+func (s *JobService) Resume(idTask string, recordProcess int) {
+	s.updateStatus(idTask, domain.JobStatusInProcess, recordProcess, "", "")
+}
+
+func (s *JobService) Fail(idTask string, recordProcess int, idError int, message string) {
+	s.updateStatus(idTask, domain.JobStatusError, recordProcess, strconv.Itoa(idError), message)
+}
+
+func (s *JobService) Pause(idTask string, recordProcess int) {
+	s.updateStatus(idTask, domain.JobStatusPaused, recordProcess, "", "")
+}
+
+func (s *JobService) Cancel(idTask string, recordProcess int) {
+	s.updateStatus(idTask, domain.JobStatusCancelled, recordProcess, "", "")
+}
+
+// Finish cierra la tarea e informa cuantas filas repetían una dirección ya geocodificada
+// y, en modo consenso, cuantas quedaron en disputa
+func (s *JobService) Finish(idTask string, recordProcess int, duplicates int, disputed int) {
+	var messages []string
+	if duplicates > 0 {
+		messages = append(messages, fmt.Sprintf("%d filas con dirección duplicada", duplicates))
+	}
+	if disputed > 0 {
+		messages = append(messages, fmt.Sprintf("%d filas en disputa entre proveedores", disputed))
+	}
+	s.updateStatus(idTask, domain.JobStatusFinish, recordProcess, "", strings.Join(messages, ", "))
+}
+
+func (s *JobService) updateStatus(idTask, status string, recordProcess int, idError, message string) {
+	if err := s.repository.UpdateJobStatus(idTask, status, recordProcess, idError, message); err != nil {
+		log.Printf("Error updating job %s to %s: %v", idTask, status, err)
+	}
+}
+
+// StatusPayload arma la respuesta de estado descrita en el README
+func StatusPayload(job domain.Job) dto.JobStatus {
+	payload := dto.JobStatus{
+		Status: job.Status,
+		Detail: dto.JobDetail{IdTask: job.IdTask},
+	}
+	if job.Status != domain.JobStatusInitial {
+		payload.Detail.RecordProcess = strconv.Itoa(job.RecordProcess)
+	}
+	if job.Status == domain.JobStatusError {
+		payload.Detail.IdError = job.IdError
+	}
+	if job.Status == domain.JobStatusError || job.Status == domain.JobStatusFinish {
+		payload.Detail.Message = job.Message
+	}
+	return payload
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+
+	"github.com/adrg/strutil"
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultMinMatchScore es la coincidencia mínima entre la dirección ingresada y el resultado del proveedor
+const defaultMinMatchScore = 0.6
+
+// matchAbbreviations expande las abreviaturas habituales para comparar con la dirección del proveedor
+var matchAbbreviations = map[string]string{
+	"AV":    "AVENIDA",
+	"AVDA":  "AVENIDA",
+	"PJE":   "PASAJE",
+	"PSJE":  "PASAJE",
+	"GRAL":  "GENERAL",
+	"STA":   "SANTA",
+	"STO":   "SANTO",
+	"DPTO":  "DEPARTAMENTO",
+	"DEPTO": "DEPARTAMENTO",
+}
+
+// matchIgnored son las palabras que solo marcan el número de la dirección
+var matchIgnored = []string{"N", "NO", "NRO", "NUM", "NUMERO"}
+
+var matchNumbers = regexp.MustCompile(`\d+`)
+
+// normalizeForMatch deja la dirección en mayúsculas sin tildes ni puntuación y con las abreviaturas expandidas
+func normalizeForMatch(address string) string {
+	var sb strings.Builder
+	for _, r := range norm.NFD.String(strings.ToUpper(address)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(' ')
+		}
+	}
+
+	var words []string
+	for _, word := range strings.Fields(sb.String()) {
+		if slices.Contains(matchIgnored, word) {
+			continue
+		}
+		if expanded, ok := matchAbbreviations[word]; ok {
+			word = expanded
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// MatchScore mide entre 0 y 1 cuánto de la dirección ingresada aparece en el resultado del proveedor.
+// El texto se compara por bigramas contra la dirección formateada y sus componentes; si el resultado es de
+// edificio o interpolado y no trae el número de la dirección, el puntaje se reduce a la mitad. Así una comuna,
+// otra calle u otro número no pasan por la dirección pedida.
+func MatchScore(address string, geo domain.Geolocation) float64 {
+	input := normalizeForMatch(address)
+	result := []string{geo.FormattedAddress}
+	for _, component := range geo.Components {
+		result = append(result, component)
+	}
+	output := normalizeForMatch(strings.Join(result, " "))
+
+	// Solo se compara el texto, el número se evalúa aparte
+	text := strings.Join(strings.Fields(matchNumbers.ReplaceAllString(input, "")), " ")
+	similarity := 1.0
+	if text != "" {
+		_, common, total, _ := strutil.NgramIntersection(text, output, 2)
+		if total > 0 {
+			similarity = float64(common) / float64(total)
+		}
+	}
+
+	// Un resultado de calle o localidad no tiene número que comparar
+	numbers := matchNumbers.FindAllString(input, -1)
+	if len(numbers) == 0 || geo.Precision == domain.PrecisionStreet || geo.Precision == domain.PrecisionLocality {
+		return similarity
+	}
+	found := matchNumbers.FindAllString(output, -1)
+	for _, n := range numbers {
+		if slices.Contains(found, n) {
+			return similarity
+		}
+	}
+	return similarity / 2
+}
+
+// checkMatch calcula la coincidencia del resultado y lo rechaza como no exacto si no alcanza el mínimo del proveedor.
+// El resultado queda marcado con el nombre del proveedor para aplicar el mismo mínimo cuando venga del cache.
+func (e geocoderEntry) checkMatch(address string, geo *domain.Geolocation) error {
+	geo.Source = e.name
+	geo.MatchScore = MatchScore(address, *geo)
+	if geo.MatchScore < e.minScore {
+		return fmt.Errorf("%w: coincidencia %.2f menor a %.2f con %q", geocoders.ErrNotExact, geo.MatchScore, e.minScore, geo.FormattedAddress)
+	}
+	return nil
+}
+
+// minScoreOf es la coincidencia mínima del proveedor que entregó el resultado, buscado por su nombre en la cadena;
+// los resultados guardados antes de registrar el nombre se buscan por tipo de proveedor. Si no está en la cadena
+// se usa la de GEOCODER_MIN_SCORE_<NOMBRE>, GEOCODER_MIN_SCORE o la por defecto
+func (s *GeolocationService) minScoreOf(geo domain.Geolocation) float64 {
+	name := geo.Source
+	for _, geocoder := range s.geocoders {
+		if name != "" && geocoder.name == name || name == "" && geocoder.provider == geo.Geocoder {
+			return geocoder.minScore
+		}
+	}
+	if name == "" {
+		name = geo.Geocoder
+	}
+	return geocoderMinScore(name, defaultMinMatchScore)
+}
+
+// cachedMatch aplica a un resultado del cache la misma coincidencia mínima que a los proveedores.
+// Los resultados guardados antes de calcular la coincidencia no la traen y se recalcula con la dirección consultada.
+func (s *GeolocationService) cachedMatch(formattedAddress string, geo *domain.Geolocation) bool {
+	if geo.MatchScore == 0 {
+		geo.MatchScore = MatchScore(formattedAddress, *geo)
+	}
+	return geo.MatchScore >= s.minScoreOf(*geo)
+}
+
+// geocoderMinScore lee la coincidencia mínima de GEOCODER_MIN_SCORE_<NOMBRE> o GEOCODER_MIN_SCORE; 0 la desactiva
+func geocoderMinScore(name string, fallback float64) float64 {
+	score, err := strconv.ParseFloat(getenv("GEOCODER_MIN_SCORE_"+strings.ToUpper(name), "GEOCODER_MIN_SCORE"), 64)
+	if err != nil || score < 0 || score > 1 {
+		return fallback
+	}
+	return score
+}
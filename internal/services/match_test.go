@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"wemaps/internal/domain"
+)
+
+func TestMatchScore(t *testing.T) {
+	providencia := domain.Geolocation{
+		FormattedAddress: "Avenida Providencia 1234, Providencia, Chile",
+		Precision:        domain.PrecisionRooftop,
+	}
+	tests := []struct {
+		name    string
+		address string
+		geo     domain.Geolocation
+		min     float64
+		max     float64
+	}{
+		{name: "misma dirección", address: "Avenida Providencia 1234, Providencia", geo: providencia, min: 0.99, max: 1},
+		{name: "abreviaturas y tildes", address: "Av. Providencia N° 1234, PROVIDENCIA", geo: providencia, min: 0.99, max: 1},
+		{name: "otro número", address: "Avenida Providencia 99, Providencia", geo: providencia, max: 0.5},
+		{name: "otra calle", address: "Pasaje Los Aromos 1234, Maipú", geo: providencia, max: 0.6},
+		{
+			name:    "resultado de calle sin número",
+			address: "Avenida Providencia 1234, Providencia",
+			geo:     domain.Geolocation{FormattedAddress: "Avenida Providencia, Providencia, Chile", Precision: domain.PrecisionStreet},
+			min:     0.99,
+			max:     1,
+		},
+		{
+			name:    "número en los componentes",
+			address: "Avenida Providencia 1234, Providencia",
+			geo: domain.Geolocation{
+				FormattedAddress: "Avenida Providencia, Providencia, Chile",
+				Components:       map[string]string{"house_number": "1234"},
+				Precision:        domain.PrecisionInterpolated,
+			},
+			min: 0.99,
+			max: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if score := MatchScore(tt.address, tt.geo); score < tt.min || score > tt.max {
+				t.Errorf("MatchScore = %.2f, se esperaba entre %.2f y %.2f", score, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// memoryCache es un cache de geolocalizaciones en memoria
+type memoryCache struct {
+	sync.Mutex
+	entries map[string]domain.Geolocation
+}
+
+func (c *memoryCache) Save(ctx context.Context, address string, geo domain.Geolocation) error {
+	c.Lock()
+	defer c.Unlock()
+	c.entries[address] = geo
+	return nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, address string) (domain.Geolocation, bool, error) {
+	c.Lock()
+	defer c.Unlock()
+	geo, ok := c.entries[address]
+	return geo, ok, nil
+}
+
+// fixedGeocoder retorna siempre el mismo resultado y cuenta las consultas
+type fixedGeocoder struct {
+	geo   domain.Geolocation
+	calls int
+}
+
+func (g *fixedGeocoder) Geocode(ctx context.Context, address string) (*domain.Geolocation, error) {
+	g.calls++
+	geo := g.geo
+	return &geo, nil
+}
+
+func TestLookupRechecksCache(t *testing.T) {
+	const address = "AVENIDA PROVIDENCIA 1234, PROVIDENCIA"
+	provider := domain.Geolocation{
+		FormattedAddress: "Avenida Providencia 1234, Providencia, Chile",
+		Geocoder:         "fixed",
+		Precision:        domain.PrecisionRooftop,
+	}
+	tests := []struct {
+		name      string
+		cached    domain.Geolocation
+		wantCalls int
+	}{
+		{name: "cache que coincide", cached: provider},
+		// Guardado antes de calcular la coincidencia: se recalcula y otro número no pasa
+		{name: "cache sin puntaje de otro número", cached: domain.Geolocation{FormattedAddress: "Avenida Providencia 99, Providencia, Chile", Geocoder: "fixed"}, wantCalls: 1},
+		{name: "cache bajo el mínimo", cached: domain.Geolocation{FormattedAddress: provider.FormattedAddress, Geocoder: "fixed", MatchScore: 0.3}, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geocoder := &fixedGeocoder{geo: provider}
+			cache := &memoryCache{entries: map[string]domain.Geolocation{address: tt.cached}}
+			s := &GeolocationService{
+				repository: cache,
+				geocoders:  []geocoderEntry{newGeocoderEntry("fixed", geocoder, geocoderLimits{concurrency: 1, batchSize: 1, minScore: defaultMinMatchScore})},
+			}
+
+			geo, err := s.GetCoordsFromAddress(context.Background(), address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if geocoder.calls != tt.wantCalls {
+				t.Errorf("consultas al proveedor = %d, se esperaban %d", geocoder.calls, tt.wantCalls)
+			}
+			if geo.FormattedAddress != provider.FormattedAddress || geo.MatchScore < defaultMinMatchScore {
+				t.Errorf("resultado %q con coincidencia %.2f", geo.FormattedAddress, geo.MatchScore)
+			}
+		})
+	}
+}
+
+func TestCachedMatchUsesChainName(t *testing.T) {
+	const address = "AVENIDA PROVIDENCIA 1234, PROVIDENCIA"
+	// El nombre en la cadena no es el tipo que informa el proveedor en Geocoder
+	var config GeocodersConfig
+	if err := json.Unmarshal([]byte(`{"geocoders":[{"name":"osm","type":"nominatim","min_score":0}]}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	cached := domain.Geolocation{
+		FormattedAddress: "Avenida Providencia 99, Providencia, Chile",
+		Geocoder:         "nominatim",
+		Precision:        domain.PrecisionRooftop,
+		MatchScore:       0.3,
+	}
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{name: "resultado con nombre de la cadena", source: "osm"},
+		{name: "resultado guardado sin nombre", source: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newGeocoderChain(config, nil)
+			geocoder := &fixedGeocoder{geo: domain.Geolocation{FormattedAddress: "Avenida Providencia 1234, Providencia, Chile", Geocoder: "nominatim"}}
+			chain[0].geocoder = geocoder
+			entry := cached
+			entry.Source = tt.source
+			s := &GeolocationService{
+				repository: &memoryCache{entries: map[string]domain.Geolocation{address: entry}},
+				geocoders:  chain,
+			}
+
+			geo, err := s.GetCoordsFromAddress(context.Background(), address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// min_score 0 de osm acepta el resultado del cache sin volver a consultar
+			if geocoder.calls != 0 || geo.FormattedAddress != cached.FormattedAddress {
+				t.Errorf("consultas al proveedor = %d, resultado %q", geocoder.calls, geo.FormattedAddress)
+			}
+		})
+	}
+}
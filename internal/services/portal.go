@@ -39,7 +39,7 @@ func (s *PortalService) InvalidateUserCache(userID int) {
 	cacheMgr.Delete(cacheMgr.reportSummaryCacheKey(userID))
 }
 
-func (s *PortalService) SaveReportInfo(idUser int, reportID int, nameReport string, infoReport map[string]string, geo domain.Geolocation, hash string, index int) (int, error) {
+func (s *PortalService) SaveReportInfo(idUser int, reportID int, nameReport string, infoReport map[string]string, columns []string, geo domain.Geolocation, hash string, index int) (int, error) {
 
 	var err error
 
@@ -51,10 +51,10 @@ func (s *PortalService) SaveReportInfo(idUser int, reportID int, nameReport stri
 	}
 	s.InvalidateUserCache(idUser)
 
-	return reportID, s.saveReportDetails(reportID, infoReport, geo, index)
+	return reportID, s.saveReportDetails(reportID, infoReport, columns, geo, index)
 }
 
-func (s *PortalService) SaveReportInfoCache(idUser int, nameReport string, infoReport map[string]string, geo domain.Geolocation, hash string, index int) (int, error) {
+func (s *PortalService) SaveReportInfoCache(idUser int, nameReport string, infoReport map[string]string, columns []string, geo domain.Geolocation, hash string, index int) (int, error) {
 	cacheMgr := GetCacheManager()
 	cacheReportKey := cacheMgr.cacheKey(nameReport, hash)
 	var reportID int
@@ -78,10 +78,10 @@ func (s *PortalService) SaveReportInfoCache(idUser int, nameReport string, infoR
 		s.InvalidateUserCache(idUser)
 	}
 
-	return reportID, s.saveReportDetails(reportID, infoReport, geo, index)
+	return reportID, s.saveReportDetails(reportID, infoReport, columns, geo, index)
 }
 
-func (s *PortalService) saveReportDetails(reportID int, infoReport map[string]string, geo domain.Geolocation, index int) error {
+func (s *PortalService) saveReportDetails(reportID int, infoReport map[string]string, columns []string, geo domain.Geolocation, index int) error {
 	addressID := 0
 	var err error
 
@@ -100,11 +100,11 @@ func (s *PortalService) saveReportDetails(reportID int, infoReport map[string]st
 			return fmt.Errorf("error linking new address to report: %v", err)
 		}
 	}
-	go func() {
-		s.repository.SaveReportColumnByIdReport(reportID, addressID, infoReport, index)
-	}()
-
-	return err
+	// Las columnas se guardan antes de retornar, el checkpoint del reporte avanza recién con la fila guardada
+	if _, err := s.repository.SaveReportColumnByIdReport(reportID, addressID, infoReport, columns, index); err != nil {
+		return fmt.Errorf("error saving report row %d: %v", index, err)
+	}
+	return nil
 }
 
 func (s *PortalService) ValidateToken(token string) (*dto.UserPortal, error) {
@@ -322,6 +322,9 @@ func (s *PortalService) GetTotalReportsAndAddress(userID int) ([]dto.CategoryCou
 
 func (s *PortalService) SetStatusReport(userID, reportID, status int) (dto.ReportResume, error) {
 	report, err := s.repository.SetStatusReport(userID, reportID, status)
+	if err == nil {
+		s.InvalidateUserCache(userID)
+	}
 	return report, err
 }
 
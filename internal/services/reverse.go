@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"wemaps/internal/domain"
+	"wemaps/internal/infrastructure/geocoders"
+)
+
+// reversePrecision son los decimales con que se redondean las coordenadas en el cache, cerca de un metro
+const reversePrecision = 5
+
+// reverseCachePrefix separa las llaves de coordenadas de las direcciones en el cache
+const reverseCachePrefix = "REVERSE "
+
+// FormatCoordinates retorna las coordenadas redondeadas como "lat,lon"
+func FormatCoordinates(lat, lon float64) string {
+	return strconv.FormatFloat(lat, 'f', reversePrecision, 64) + "," + strconv.FormatFloat(lon, 'f', reversePrecision, 64)
+}
+
+// ParseCoordinates lee un par "lat,lon" y verifica que esté en rango
+func ParseCoordinates(text string) (float64, float64, error) {
+	latText, lonText, ok := strings.Cut(text, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("coordenadas inválidas %q, se espera lat,lon", text)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("latitud inválida %q", latText)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("longitud inválida %q", lonText)
+	}
+	return lat, lon, ValidateCoordinates(lat, lon)
+}
+
+// ValidateCoordinates verifica que latitud y longitud estén en rango y no sean el punto 0,0
+func ValidateCoordinates(lat, lon float64) error {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return fmt.Errorf("coordenadas fuera de rango: %v,%v", lat, lon)
+	}
+	if lat == 0 && lon == 0 {
+		return errors.New("coordenadas vacías")
+	}
+	return nil
+}
+
+// ReverseGeocode obtiene la dirección de las coordenadas con los proveedores de la cadena que admiten
+// geocodificación inversa. El cache usa las coordenadas redondeadas a reversePrecision decimales.
+func (s *GeolocationService) ReverseGeocode(ctx context.Context, lat, lon float64) (domain.Geolocation, error) {
+	if err := ValidateCoordinates(lat, lon); err != nil {
+		return domain.Geolocation{}, err
+	}
+	coordinates := FormatCoordinates(lat, lon)
+	geo, err := s.shared(ctx, reverseCachePrefix+coordinates, func() (domain.Geolocation, error) {
+		return s.reverseLookup(ctx, lat, lon, reverseCachePrefix+coordinates)
+	})
+	if err == nil {
+		geo.OriginAddress = coordinates
+	}
+	return geo, err
+}
+
+// reverseLookup consulta el cache y luego los proveedores con geocodificación inversa en orden
+func (s *GeolocationService) reverseLookup(ctx context.Context, lat, lon float64, key string) (domain.Geolocation, error) {
+	result, exists, err := s.repository.Get(ctx, key)
+	if err != nil {
+		return domain.Geolocation{}, err
+	}
+	if exists {
+		return result, nil
+	}
+
+	var failures chainFailures
+	for _, geocoder := range s.geocoders {
+		if !geocoder.reverse {
+			continue
+		}
+		geo, err := geocoder.ReverseGeocode(ctx, lat, lon)
+		if ctx.Err() != nil {
+			return domain.Geolocation{}, ctx.Err()
+		}
+		if err == nil && geo == nil {
+			err = geocoders.ErrNotFound
+		}
+		if err != nil {
+			failures.add(geocoder.name, err)
+			continue
+		}
+		return s.store(ctx, FormatCoordinates(lat, lon), key, geo)
+	}
+	if len(failures.messages) == 0 {
+		return domain.Geolocation{}, errors.New("ningún geocodificador de la cadena admite geocodificación inversa")
+	}
+	return domain.Geolocation{}, failures.err()
+}
+
+// ReverseGeocodeBatch obtiene la dirección de cada fila con el mismo pool de workers, orden y deduplicación que
+// GeocodeBatch; coordinates compone el par "lat,lon" de cada fila.
+func (s *GeolocationService) ReverseGeocodeBatch(ctx context.Context, rows ReportRows, coordinates func(row []string) string, onResult func(BatchResult) error) error {
+	return s.runBatch(ctx, rows, coordinates, 1, s.reverseCoordinates, onResult)
+}
+
+// reverseCoordinates resuelve cada par "lat,lon" por separado, ningún proveedor ofrece lotes inversos
+func (s *GeolocationService) reverseCoordinates(ctx context.Context, coordinates []string) []batchLookup {
+	results := make([]batchLookup, len(coordinates))
+	for i, text := range coordinates {
+		lat, lon, err := ParseCoordinates(text)
+		if err != nil {
+			results[i].err = err
+			continue
+		}
+		results[i].geo, results[i].err = s.ReverseGeocode(ctx, lat, lon)
+	}
+	return results
+}
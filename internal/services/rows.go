@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"wemaps/internal/infrastructure/spreadsheet"
+)
+
+// ReportRows recorre las filas de un reporte sin cargarlas completas en memoria
+type ReportRows interface {
+	Columns() []string
+	// Next retorna la siguiente fila con una celda por columna, o io.EOF al terminar
+	Next() ([]string, error)
+	Close() error
+}
+
+// ReportSource describe un archivo subido al servidor como origen de las filas del reporte
+type ReportSource struct {
+	Type     string                `json:"type"`
+	Path     string                `json:"path"`
+	Checksum string                `json:"checksum"`
+	Format   spreadsheet.CSVFormat `json:"format"`
+	Sheet    string                `json:"sheet,omitempty"`
+	Rows     int                   `json:"rows"`
+}
+
+const (
+	SourceCSV  = "csv"
+	SourceXLSX = "xlsx"
+)
+
+// TotalRows retorna la cantidad de filas a geocodificar
+func (r CoordsReportRequest) TotalRows() int {
+	if r.Source != nil {
+		return r.Source.Rows
+	}
+	if len(r.Columns) == 0 {
+		return 0
+	}
+	return len(r.Values[r.Columns[0]])
+}
+
+// OpenRows abre las filas del reporte desde el archivo subido o desde los valores del request
+func (r CoordsReportRequest) OpenRows() (ReportRows, error) {
+	if r.Source == nil {
+		return &memoryRows{columns: r.Columns, values: r.Values, total: r.TotalRows()}, nil
+	}
+
+	switch r.Source.Type {
+	case SourceCSV:
+		file, err := os.Open(r.Source.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error abriendo archivo del reporte: %v", err)
+		}
+		reader, err := spreadsheet.NewCSVReader(file, r.Source.Format)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &fileRows{rowReader: reader, closer: file}, nil
+	case SourceXLSX:
+		reader, err := spreadsheet.NewXLSXReader(r.Source.Path, spreadsheet.XLSXFormat{
+			Sheet:     r.Source.Sheet,
+			HeaderRow: r.Source.Format.HeaderRow,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &fileRows{rowReader: reader, closer: reader}, nil
+	default:
+		return nil, fmt.Errorf("origen de reporte no soportado: %s", r.Source.Type)
+	}
+}
+
+type memoryRows struct {
+	columns []string
+	values  map[string][]string
+	index   int
+	total   int
+}
+
+func (m *memoryRows) Columns() []string {
+	return m.columns
+}
+
+func (m *memoryRows) Next() ([]string, error) {
+	if m.index >= m.total {
+		return nil, io.EOF
+	}
+	row := make([]string, len(m.columns))
+	for i, col := range m.columns {
+		if values := m.values[col]; m.index < len(values) {
+			row[i] = values[m.index]
+		}
+	}
+	m.index++
+	return row, nil
+}
+
+func (m *memoryRows) Close() error {
+	return nil
+}
+
+type rowReader interface {
+	Columns() []string
+	Next() ([]string, error)
+}
+
+// fileRows lee las filas desde un archivo subido
+type fileRows struct {
+	rowReader
+	closer io.Closer
+}
+
+func (f *fileRows) Close() error {
+	return f.closer.Close()
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"wemaps/internal/infrastructure/spreadsheet"
+)
+
+// sniffSize es el tamaño de la muestra usada para detectar el formato del CSV
+const sniffSize = 64 * 1024
+
+// defaultMaxUploadBytes es el tamaño máximo de una carga si no se define UPLOAD_MAX_BYTES
+const defaultMaxUploadBytes = 100 << 20
+
+func uploadsDir() string {
+	return cmp.Or(os.Getenv("UPLOADS_DIR"), "uploads")
+}
+
+// MaxUploadBytes es el tamaño máximo del cuerpo de una carga, incluidos los campos del formulario
+func MaxUploadBytes() int64 {
+	limit, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_BYTES"), 10, 64)
+	if err != nil || limit <= 0 {
+		return defaultMaxUploadBytes
+	}
+	return limit
+}
+
+// uploadRefs cuenta las cargas y tareas en curso que usan cada archivo; como el nombre es el checksum,
+// dos cargas del mismo archivo comparten la ruta y el archivo solo se elimina cuando ninguna lo usa
+var uploadRefs = struct {
+	sync.Mutex
+	count map[string]int
+}{count: make(map[string]int)}
+
+// AcquireUpload registra un uso del archivo, por ejemplo una tarea retomada al iniciar el servidor
+func AcquireUpload(path string) {
+	uploadRefs.Lock()
+	defer uploadRefs.Unlock()
+	uploadRefs.count[path]++
+}
+
+// ReleaseUpload libera un uso del archivo y lo elimina cuando ninguna carga ni tarea lo usa
+func ReleaseUpload(path string) {
+	uploadRefs.Lock()
+	defer uploadRefs.Unlock()
+	if uploadRefs.count[path]--; uploadRefs.count[path] > 0 {
+		return
+	}
+	delete(uploadRefs.count, path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error eliminando archivo subido %s: %v", path, err)
+	}
+}
+
+// StoreUpload guarda el archivo subido en disco sin cargarlo en memoria.
+// El nombre del archivo es su checksum, así una misma carga genera la misma definición de tarea.
+// El archivo queda registrado con AcquireUpload y se elimina con ReleaseUpload.
+func StoreUpload(r io.Reader, extension string) (string, string, error) {
+	dir := uploadsDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("error creando directorio de cargas: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "upload-*")
+	if err != nil {
+		return "", "", fmt.Errorf("error creando archivo temporal: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), r); err != nil {
+		tmp.Close()
+		// %w conserva el error de tamaño máximo para responder 413
+		return "", "", fmt.Errorf("error guardando archivo: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", fmt.Errorf("error guardando archivo: %v", err)
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	path := filepath.Join(dir, checksum+extension)
+	// El archivo se registra antes de moverlo, así otra carga no lo elimina entremedio
+	uploadRefs.Lock()
+	defer uploadRefs.Unlock()
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", fmt.Errorf("error guardando archivo: %v", err)
+	}
+	uploadRefs.count[path]++
+	return path, checksum, nil
+}
+
+// SniffCSVFile detecta el formato del CSV a partir del comienzo del archivo
+func SniffCSVFile(path string) (spreadsheet.CSVFormat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return spreadsheet.CSVFormat{}, err
+	}
+	defer file.Close()
+
+	sample := make([]byte, sniffSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return spreadsheet.CSVFormat{}, err
+	}
+	return spreadsheet.SniffCSV(sample[:n]), nil
+}
+
+// SniffXLSXFile valida la hoja elegida del libro, o usa la hoja activa, y detecta la fila de encabezado
+func SniffXLSXFile(path, sheet string) (spreadsheet.XLSXFormat, error) {
+	return spreadsheet.SniffXLSX(path, sheet)
+}
+
+// NewCSVSource recorre el archivo una vez para obtener el encabezado y contar las filas
+func NewCSVSource(path, checksum string, format spreadsheet.CSVFormat) (*ReportSource, []string, error) {
+	return countSource(&ReportSource{
+		Type:     SourceCSV,
+		Path:     path,
+		Checksum: checksum,
+		Format:   format,
+	})
+}
+
+// NewXLSXSource recorre la hoja del libro una vez para obtener el encabezado y contar las filas
+func NewXLSXSource(path, checksum string, format spreadsheet.XLSXFormat) (*ReportSource, []string, error) {
+	return countSource(&ReportSource{
+		Type:     SourceXLSX,
+		Path:     path,
+		Checksum: checksum,
+		Format:   spreadsheet.CSVFormat{HeaderRow: format.HeaderRow},
+		Sheet:    format.Sheet,
+	})
+}
+
+func countSource(source *ReportSource) (*ReportSource, []string, error) {
+	rows, err := CoordsReportRequest{Source: source}.OpenRows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for {
+		_, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error leyendo fila %d: %v", source.Rows+1, err)
+		}
+		source.Rows++
+	}
+	return source, rows.Columns(), nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadRefs(t *testing.T) {
+	t.Setenv("UPLOADS_DIR", t.TempDir())
+
+	// Dos cargas del mismo archivo comparten la ruta
+	first, checksum, err := StoreUpload(strings.NewReader("Dirección\nCalle 1\n"), ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := StoreUpload(strings.NewReader("Dirección\nCalle 1\n"), ".csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second || filepath.Base(first) != checksum+".csv" {
+		t.Fatalf("rutas %q y %q, checksum %q", first, second, checksum)
+	}
+
+	ReleaseUpload(first)
+	if _, err := os.Stat(first); err != nil {
+		t.Fatalf("el archivo se eliminó con otra carga usándolo: %v", err)
+	}
+	ReleaseUpload(second)
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Fatalf("el archivo sigue en disco: %v", err)
+	}
+}
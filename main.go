@@ -9,6 +9,7 @@ import (
 	"os"
 	"wemaps/internal/adapters/http"
 	"wemaps/internal/infrastructure/repository"
+	"wemaps/internal/services"
 )
 
 type TLSConfig struct {
@@ -17,8 +18,9 @@ type TLSConfig struct {
 }
 
 func main() {
-	var httpsConfigPath string
+	var httpsConfigPath, geocodersConfigPath string
 	flag.StringVar(&httpsConfigPath, "https", "", "Ruta al archivo JSON con configuración TLS (cert y key)")
+	flag.StringVar(&geocodersConfigPath, "geocoders", "", "Ruta al archivo JSON con la cadena de geocodificadores")
 	flag.Parse()
 
 	port := cmp.Or(os.Getenv("PORT"), "80")
@@ -43,10 +45,18 @@ func main() {
 		keyFile = tlsConfig.KeyFile
 	}
 
+	// La cadena de geocodificadores se valida antes de conectarse a las bases
+	geocodersConfig, err := services.LoadGeocodersConfig(geocodersConfigPath)
+	if err != nil {
+		fmt.Printf("Error en la configuración de geocodificadores: %v\n", err)
+		os.Exit(1)
+	}
+
 	reporPortal, errorPostgress := repository.NewPostgresDBRepository()
 	if errorPostgress != nil {
-		fmt.Println("Error: No se pudo inicializar el repositorio Postgress")
-
+		// Las tareas, los reportes y su reanudación dependen de Postgres
+		fmt.Printf("Error: No se pudo inicializar el repositorio Postgress: %v\n", errorPostgress)
+		os.Exit(1)
 	}
 
 	repoAddress, errorMongo := repository.NewMongoDBRepository()
@@ -60,7 +70,7 @@ func main() {
 		}
 	}()
 
-	httpServer := http.NewServer(repoAddress, reporPortal)
+	httpServer := http.NewServer(repoAddress, reporPortal, reporPortal, geocodersConfig)
 
 	if err := httpServer.StartServer(port, certFile, keyFile); err != nil {
 		fmt.Printf("Error iniciando servidor: %v\n", err)